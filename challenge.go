@@ -0,0 +1,150 @@
+package pos
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoChallenge is returned when a response is given to a session that has
+// no outstanding challenge.
+var ErrNoChallenge = errors.New("no outstanding challenge")
+
+// A type implementing the Clock interface provides the current time to a
+// session. It allows the timing logic to be tested deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock is a Clock backed by time.Now.
+var SystemClock Clock = systemClock{}
+
+// Challenge contains the values sent to a prover to start the solve phase.
+type Challenge struct {
+	PreseedIndices []int64   `json:"preseed_indices"` // The initial preseed indices.
+	Mask           []byte    `json:"mask"`            // The mask applied to the preseed.
+	Issued         time.Time `json:"issued"`          // The time the timer was started.
+	Deadline       time.Time `json:"deadline"`        // The latest time a response is accepted.
+}
+
+// Verdict is the outcome of a response to a challenge.
+type Verdict struct {
+	Accepted bool          `json:"accepted"` // True if the solution was correct and on time.
+	Duration time.Duration `json:"duration"` // The time between issuing the challenge and the response.
+	Late     bool          `json:"late"`     // True if the response arrived after the deadline.
+	Mismatch int           `json:"mismatch"` // The position of the first mismatched solution byte or -1.
+}
+
+// Session tracks a single challenge/response exchange for a puzzle and
+// enforces the allowed time between issuing a challenge and receiving the
+// response.
+type Session struct {
+	Puzzle  *Puzzle       // The puzzle the prover has prepared.
+	Allowed time.Duration // The allowed time for a response.
+	Clock   Clock         // The clock used to time the exchange.
+
+	challenge *Challenge
+}
+
+func NewSession(puzzle *Puzzle, allowed time.Duration, clock Clock) (*Session, error) {
+	if clock == nil {
+		clock = SystemClock
+	}
+
+	return &Session{
+		Puzzle:  puzzle,
+		Allowed: allowed,
+		Clock:   clock,
+	}, nil
+}
+
+// Issue selects random preseed indices and a mask and starts the timer. Any
+// previously issued challenge is discarded.
+func (s *Session) Issue() (challenge *Challenge, err error) {
+	if s.Puzzle == nil {
+		return nil, ErrNoPuzzle
+	}
+
+	err = s.Puzzle.Check()
+	if err != nil {
+		return nil, err
+	}
+
+	err = CheckNotSeekable(s.Puzzle.PRNG)
+	if err != nil {
+		return nil, err
+	}
+
+	size := len(s.Puzzle.PRNG.GetSeed())
+
+	seed, err := NewRandomBytes(size)
+	if err != nil {
+		return nil, err
+	}
+
+	preseedIndices, err := s.Puzzle.PreseedIndices(int64(size), seed)
+	if err != nil {
+		return nil, err
+	}
+
+	mask, err := NewRandomBytes(size)
+	if err != nil {
+		return nil, err
+	}
+
+	issued := s.Clock.Now()
+
+	s.challenge = &Challenge{
+		PreseedIndices: preseedIndices,
+		Mask:           mask,
+		Issued:         issued,
+		Deadline:       issued.Add(s.Allowed),
+	}
+
+	return s.challenge, nil
+}
+
+// Challenge returns the outstanding challenge or nil if there is none.
+func (s *Session) Challenge() *Challenge {
+	return s.challenge
+}
+
+// Respond stops the timer and checks the solution against the outstanding
+// challenge. The duration is measured before the solution is verified so
+// that verification time is not charged to the prover. The challenge is
+// consumed regardless of the verdict.
+func (s *Session) Respond(solution []byte) (verdict *Verdict, err error) {
+	received := s.Clock.Now()
+
+	challenge := s.challenge
+	if challenge == nil {
+		return nil, ErrNoChallenge
+	}
+	s.challenge = nil
+
+	verdict = &Verdict{
+		Duration: received.Sub(challenge.Issued),
+		Late:     received.After(challenge.Deadline),
+		Mismatch: -1,
+	}
+
+	verifier, err := NewVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := verifier.Verify(s.Puzzle, challenge.PreseedIndices, challenge.Mask, solution)
+	if err != nil {
+		return nil, err
+	}
+
+	verdict.Mismatch = verifier.Mismatch
+	verdict.Accepted = ok && !verdict.Late
+
+	return verdict, nil
+}
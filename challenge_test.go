@@ -0,0 +1,158 @@
+package pos_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/calebcase/pos"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestSessionRespond(t *testing.T) {
+	allowed := 10 * time.Second
+
+	tests := []struct {
+		name     string
+		elapsed  time.Duration
+		accepted bool
+		late     bool
+	}{
+		{"on time", time.Second, true, false},
+		{"at deadline", allowed, true, false},
+		{"late", allowed + time.Nanosecond, false, true},
+	}
+
+	puzzle := newPuzzle(t, "aes", 4096)
+
+	solver, err := pos.NewMemorySolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = solver.Prepare(puzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{time.Unix(1000, 0)}
+
+			session, err := pos.NewSession(puzzle, allowed, clock)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			challenge, err := session.Issue()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !challenge.Deadline.Equal(clock.now.Add(allowed)) {
+				t.Fatalf("deadline %s, want %s", challenge.Deadline, clock.now.Add(allowed))
+			}
+
+			solution, err := solver.Solve(puzzle, challenge.PreseedIndices, challenge.Mask)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			clock.now = clock.now.Add(tt.elapsed)
+
+			verdict, err := session.Respond(solution)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if verdict.Accepted != tt.accepted || verdict.Late != tt.late {
+				t.Errorf("accepted %t late %t, want accepted %t late %t", verdict.Accepted, verdict.Late, tt.accepted, tt.late)
+			}
+
+			if verdict.Duration != tt.elapsed {
+				t.Errorf("duration %s, want %s", verdict.Duration, tt.elapsed)
+			}
+
+			if verdict.Mismatch != -1 {
+				t.Errorf("mismatch %d, want -1", verdict.Mismatch)
+			}
+		})
+	}
+}
+
+func TestSessionRespondWrongSolution(t *testing.T) {
+	clock := &fakeClock{time.Unix(1000, 0)}
+
+	session, err := pos.NewSession(newPuzzle(t, "aes", 4096), time.Second, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = session.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verdict, err := session.Respond(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if verdict.Accepted || verdict.Mismatch != 0 {
+		t.Errorf("accepted %t mismatch %d, want rejected at 0", verdict.Accepted, verdict.Mismatch)
+	}
+}
+
+func TestSessionNoChallenge(t *testing.T) {
+	clock := &fakeClock{time.Unix(1000, 0)}
+
+	session, err := pos.NewSession(newPuzzle(t, "aes", 4096), time.Second, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = session.Respond(nil)
+	if err != pos.ErrNoChallenge {
+		t.Fatalf("error %v, want %v", err, pos.ErrNoChallenge)
+	}
+
+	_, err = session.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = session.Respond(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The challenge is consumed by the first response.
+	_, err = session.Respond(nil)
+	if err != pos.ErrNoChallenge {
+		t.Fatalf("error %v, want %v", err, pos.ErrNoChallenge)
+	}
+
+	if session.Challenge() != nil {
+		t.Fatal("challenge outstanding after response")
+	}
+}
+
+func TestSessionIssueSeekable(t *testing.T) {
+	clock := &fakeClock{time.Unix(1000, 0)}
+
+	session, err := pos.NewSession(newPuzzle(t, "aes-ctr", 4096), time.Second, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = session.Issue()
+	if err != pos.SeekablePRNGError("aes-ctr") {
+		t.Fatalf("error %v, want %v", err, pos.SeekablePRNGError("aes-ctr"))
+	}
+}
@@ -0,0 +1,147 @@
+package pos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// CheckReport summarizes a comparison of an image with its PRNG stream.
+type CheckReport struct {
+	BlockSize     int64         `json:"block_size"`     // The size in bytes of the blocks.
+	Blocks        int64         `json:"blocks"`         // The number of blocks in the claim.
+	BlocksChecked int64         `json:"blocks_checked"` // The number of blocks compared.
+	BadBlocks     int64         `json:"bad_blocks"`     // The number of compared blocks that differ.
+	FirstBad      int64         `json:"first_bad"`      // The offset of the first differing byte or -1.
+	BytesChecked  int64         `json:"bytes_checked"`  // The number of image bytes compared.
+	BytesRead     int64         `json:"bytes_read"`     // The number of PRNG bytes generated.
+	Duration      time.Duration `json:"duration"`       // The time taken by the check.
+}
+
+// Rate returns the number of image bytes checked per second.
+func (r *CheckReport) Rate() float64 {
+	return float64(r.BytesChecked) / r.Duration.Seconds()
+}
+
+// SampleSize returns the number of blocks to sample to detect, with the given
+// confidence, an image in which at least the given fraction of blocks are
+// bad. For example a confidence of 0.99 and a fraction of 0.001 requires 4603
+// samples regardless of the size of the image.
+func SampleSize(confidence, fraction float64) int64 {
+	if confidence <= 0 || fraction >= 1 {
+		return 1
+	}
+
+	if confidence >= 1 || fraction <= 0 {
+		return math.MaxInt64
+	}
+
+	return int64(math.Ceil(math.Log(1-confidence) / math.Log(1-fraction)))
+}
+
+// Check compares the image with the PRNG stream that Prepare would write in
+// blocks of BlockSize bytes. If samples is positive and less than the number
+// of blocks in the claim, only that many blocks chosen uniformly at random are
+// compared. Otherwise the whole image is compared. Unreadable blocks are
+// counted as bad.
+func (s *DiskSolver) Check(puzzle *Puzzle, samples int64) (report *CheckReport, err error) {
+	start := time.Now()
+
+	if s.BlockSize <= 0 {
+		return nil, BlockSizeError(s.BlockSize)
+	}
+
+	prng, err := puzzle.PRNG.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	blockSize := int64(s.BlockSize)
+	last := make([]byte, blockSize, blockSize)
+	image := make([]byte, blockSize, blockSize)
+
+	report = &CheckReport{
+		BlockSize: blockSize,
+		Blocks:    (puzzle.Claim + blockSize - 1) / blockSize,
+		FirstBad:  -1,
+	}
+
+	if samples <= 0 || samples > report.Blocks {
+		samples = report.Blocks
+	}
+
+	seed, err := NewRandomBytes(8)
+	if err != nil {
+		return nil, err
+	}
+
+	// Selection sampling: each block is chosen with probability equal to
+	// the number of samples still needed over the number of blocks left.
+	random := rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(seed))))
+
+	var generated int64
+
+	for i, block := int64(0), int64(0); i < puzzle.Claim; i, block = i+blockSize, block+1 {
+		needed := samples - report.BlocksChecked
+		if random.Int63n(report.Blocks-block) >= needed {
+			continue
+		}
+
+		// Fast forward the PRNG past the blocks that were not chosen.
+		read, err := skip(prng, i-generated)
+		report.BytesRead += read
+		if err != nil {
+			return nil, err
+		}
+
+		size := blockSize
+		if puzzle.Claim-i < size {
+			size = puzzle.Claim - i
+		}
+
+		n, err := io.ReadFull(prng, last[:size])
+		report.BytesRead += int64(n)
+		if err != nil {
+			return nil, err
+		}
+
+		generated = i + size
+
+		report.BlocksChecked++
+		report.BytesChecked += size
+
+		bad := int64(-1)
+
+		_, err = s.out.Seek(s.Offset+i, io.SeekStart)
+		if err == nil {
+			_, err = io.ReadFull(s.out, image[:size])
+		}
+
+		if err != nil {
+			bad = i
+		} else if !bytes.Equal(image[:size], last[:size]) {
+			for j := int64(0); j < size; j++ {
+				if image[j] != last[j] {
+					bad = i + j
+
+					break
+				}
+			}
+		}
+
+		if bad >= 0 {
+			report.BadBlocks++
+
+			if report.FirstBad < 0 {
+				report.FirstBad = bad
+			}
+		}
+	}
+
+	report.Duration = time.Since(start)
+
+	return report, nil
+}
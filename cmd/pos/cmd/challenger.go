@@ -0,0 +1,12 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var challengerCmd = &cobra.Command{
+	Use:   "challenger",
+	Short: "Network challenger commands",
+}
+
+func init() {
+	rootCmd.AddCommand(challengerCmd)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"time"
+
+	"github.com/calebcase/pos"
+	"github.com/calebcase/pos/lib/protocol"
+	"github.com/spf13/cobra"
+)
+
+var challengerRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Challenge a prover over TCP",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, err := cmd.Flags().GetString("addr")
+		if err != nil {
+			return err
+		}
+
+		allowed, err := cmd.Flags().GetDuration("allowed")
+		if err != nil {
+			return err
+		}
+
+		savePath, err := cmd.Flags().GetString("save-puzzle")
+		if err != nil {
+			return err
+		}
+
+		// A prover keeps the image it prepared, so it can be challenged
+		// again by giving the same puzzle. A new puzzle is saved before it
+		// is sent so that it is kept even if the exchange fails.
+		newChallengePuzzle := func(claim int64) (*pos.Puzzle, error) {
+			p, err := newPuzzle(cmd, claim)
+			if err != nil {
+				return nil, err
+			}
+
+			if savePath != "" {
+				err = savePuzzle(savePath, p)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			return p, nil
+		}
+
+		if cmd.Flags().Changed("puzzle") {
+			p, err := readPuzzle(cmd)
+			if err != nil {
+				return err
+			}
+
+			newChallengePuzzle = func(claim int64) (*pos.Puzzle, error) {
+				return p, nil
+			}
+		}
+
+		challenger, err := protocol.NewChallenger(newChallengePuzzle, allowed)
+		if err != nil {
+			return err
+		}
+
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		verdict, err := challenger.Run(conn)
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(os.Stdout).Encode(verdict)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+// savePuzzle writes the puzzle config to path.
+func savePuzzle(path string, puzzle *pos.Puzzle) (err error) {
+	output, err := os.Create(path)
+	if err != nil {
+		return IOError{err}
+	}
+	defer output.Close()
+
+	err = json.NewEncoder(output).Encode(puzzle)
+	if err != nil {
+		return err
+	}
+
+	return output.Sync()
+}
+
+func init() {
+	challengerCmd.AddCommand(challengerRunCmd)
+
+	challengerRunCmd.PersistentFlags().String("addr", "", "Address of the prover")
+	cobra.MarkFlagRequired(challengerRunCmd.PersistentFlags(), "addr")
+
+	challengerRunCmd.PersistentFlags().Duration("allowed", 10*time.Second, "Allowed time for a solution")
+	challengerRunCmd.PersistentFlags().StringP("puzzle", "p", "", "Path to a puzzle config to challenge with (default a new puzzle)")
+	challengerRunCmd.PersistentFlags().String("save-puzzle", "", "Path to save a new puzzle to so the prover can be challenged with it again")
+
+	addPuzzleFlags(challengerRunCmd)
+}
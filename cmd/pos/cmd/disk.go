@@ -1,6 +1,12 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"errors"
+	"os"
+
+	"github.com/calebcase/pos"
+	"github.com/spf13/cobra"
+)
 
 var diskCmd = &cobra.Command{
 	Use:   "disk",
@@ -12,6 +18,91 @@ func init() {
 
 	diskCmd.PersistentFlags().StringP("puzzle", "p", "", "Path to a puzzle config")
 
-	diskCmd.PersistentFlags().StringP("image", "i", "", "Path to a image file")
-	cobra.MarkFlagRequired(diskCmd.PersistentFlags(), "image")
+	diskCmd.PersistentFlags().StringSliceP("image", "i", nil, "Path to a image file (repeat to shard the claim across files)")
+	diskCmd.PersistentFlags().StringP("manifest", "m", "", "Path to the manifest of a sharded image (required with multiple images)")
+}
+
+// imagePaths returns the image paths given and whether the image is sharded.
+// No paths are returned for a sharded image given only by its manifest.
+func imagePaths(cmd *cobra.Command) (paths []string, sharded bool, err error) {
+	paths, err = cmd.Flags().GetStringSlice("image")
+	if err != nil {
+		return nil, false, err
+	}
+
+	sharded = len(paths) > 1 || cmd.Flags().Changed("manifest")
+
+	if len(paths) == 0 && !sharded {
+		return nil, false, UsageError{errors.New("no image given")}
+	}
+
+	return paths, sharded, nil
+}
+
+// imagePath returns the image path for commands that only support a single
+// image.
+func imagePath(cmd *cobra.Command) (path string, err error) {
+	paths, sharded, err := imagePaths(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	if sharded {
+		return "", UsageError{errors.New("sharded images are not supported by this command")}
+	}
+
+	return paths[0], nil
+}
+
+// openImage opens the image at path. A missing image is reported as an
+// ImageError and any other failure as an IOError.
+func openImage(path string, flag int, perm os.FileMode) (*os.File, error) {
+	image, err := os.OpenFile(path, flag, perm)
+	if os.IsNotExist(err) {
+		return nil, ImageError{path, err}
+	}
+	if err != nil {
+		return nil, IOError{err}
+	}
+
+	return image, nil
+}
+
+// headerCheckpoint returns a DiskSolver.Checkpoint that records the progress
+// in the image header and syncs it.
+func headerCheckpoint(image *os.File, h *pos.ImageHeader) func(prepared int64) error {
+	return func(prepared int64) error {
+		h.Prepared = prepared
+
+		err := h.Write(image)
+		if err != nil {
+			return err
+		}
+
+		return image.Sync()
+	}
+}
+
+// imageOffset returns the offset of the claimed space in the image. If the
+// image has a header it must match the puzzle and be fully prepared.
+func imageOffset(image *os.File, puzzle *pos.Puzzle) (offset int64, err error) {
+	header, err := pos.ReadImageHeader(image)
+	if err == pos.ErrNoImageHeader {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, ImageError{image.Name(), err}
+	}
+
+	err = header.Check(puzzle)
+	if err != nil {
+		return 0, ImageError{image.Name(), err}
+	}
+
+	err = header.Complete()
+	if err != nil {
+		return 0, ImageError{image.Name(), err}
+	}
+
+	return pos.ImageHeaderSize, nil
 }
@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"encoding/json"
+	"errors"
 	"os"
 
 	"github.com/calebcase/pos"
@@ -11,61 +11,220 @@ import (
 var diskPrepareCmd = &cobra.Command{
 	Use:   "prepare",
 	Short: "Prepare a disk solver",
-	Run: func(cmd *cobra.Command, args []string) {
-		input := os.Stdin
-		if cmd.Flags().Changed("puzzle") {
-			path, err := cmd.Flags().GetString("puzzle")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := readPuzzle(cmd)
+		if err != nil {
+			return err
+		}
+
+		paths, sharded, err := imagePaths(cmd)
+		if err != nil {
+			return err
+		}
+
+		if sharded {
+			err = prepareSharded(cmd, paths, p)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		}
+
+		path := paths[0]
+
+		workers, err := cmd.Flags().GetInt("workers")
+		if err != nil {
+			return err
+		}
+
+		resume, err := cmd.Flags().GetBool("resume")
+		if err != nil {
+			return err
+		}
+
+		header, err := cmd.Flags().GetBool("header")
+		if err != nil {
+			return err
+		}
+
+		interval, err := cmd.Flags().GetInt64("checkpoint-interval")
+		if err != nil {
+			return err
+		}
+
+		direct, err := cmd.Flags().GetBool("direct")
+		if err != nil {
+			return err
+		}
+
+		// Check the flags before the image is truncated.
+		if workers > 1 {
+			if resume || direct {
+				return UsageError{errors.New("--workers cannot be combined with --resume or --direct")}
+			}
+
+			switch p.PRNG.(type) {
+			case pos.SegmentedPRNG, pos.SeekablePRNG:
+			default:
+				return UsageError{errors.New("--workers requires a segmented or seekable PRNG")}
+			}
+		}
+
+		var image *os.File
+		var h *pos.ImageHeader
+
+		if resume {
+			image, err = openImage(path, os.O_RDWR, 0)
+			if err != nil {
+				return err
+			}
+			defer image.Close()
+
+			h, err = pos.ReadImageHeader(image)
 			if err != nil {
-				panic(err)
+				return ImageError{path, err}
 			}
 
-			if path != "-" {
-				input, err = os.Open(path)
+			err = h.Check(p)
+			if err != nil {
+				return ImageError{path, err}
+			}
+		} else {
+			image, err = openImage(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+			if err != nil {
+				return err
+			}
+			defer image.Close()
+
+			if header {
+				h, err = pos.NewImageHeader(p)
 				if err != nil {
-					panic(err)
+					return err
+				}
+
+				err = h.Write(image)
+				if err != nil {
+					return err
 				}
-				defer input.Close()
 			}
 		}
 
-		var p puzzle
+		// The header is written through the page cache since it is small
+		// and unaligned. Only the claimed space is written directly.
+		data := image
+
+		if direct {
+			data, err = pos.OpenDirect(path, os.O_RDWR, 0)
+			if err != nil {
+				return err
+			}
+			defer data.Close()
+		}
 
-		err := json.NewDecoder(input).Decode(&p)
+		diskSolver, err := pos.NewDiskSolver(data)
 		if err != nil {
-			panic(err)
+			return err
 		}
 
-		path, err := cmd.Flags().GetString("image")
+		diskSolver.Direct = direct
+
+		diskSolver.Sync, err = cmd.Flags().GetBool("sync")
 		if err != nil {
-			panic(err)
+			return err
 		}
 
-		image, err := os.Create(path)
+		diskSolver.BlockSize, err = cmd.Flags().GetInt("block-size")
 		if err != nil {
-			panic(err)
+			return err
 		}
-		defer image.Close()
 
-		diskSolver, err := pos.NewDiskSolver(image)
+		diskSolver.DoubleBuffer, err = cmd.Flags().GetBool("double-buffer")
 		if err != nil {
-			panic(err)
+			return err
 		}
 
-		puz := &pos.Puzzle{
-			Claim:         p.Claim,
-			PRNG:          p.PRNG,
-			PreseedRounds: p.PreseedRounds,
-			IndexSize:     p.IndexSize,
-			SolutionSize:  p.SolutionSize,
+		var prepared int64
+
+		if h != nil {
+			prepared = h.Prepared
+
+			diskSolver.Offset = pos.ImageHeaderSize
+			diskSolver.CheckpointInterval = interval
+			diskSolver.Checkpoint = headerCheckpoint(image, h)
+		}
+
+		if workers > 1 {
+			err = prepareConcurrent(image, h, p, diskSolver.BlockSize, workers, diskSolver.Sync)
+			if err != nil {
+				return err
+			}
+
+			return nil
 		}
 
-		err = diskSolver.Prepare(puz)
+		err = diskSolver.Resume(p, prepared)
 		if err != nil {
-			panic(err)
+			return err
 		}
+
+		return nil
 	},
 }
 
+// prepareConcurrent prepares the image with a ConcurrentDiskSolver using the
+// given number of workers. The header, if any, is updated once the image is
+// complete and synced so that it never claims data that is not durable.
+func prepareConcurrent(image *os.File, h *pos.ImageHeader, puzzle *pos.Puzzle, blockSize, workers int, sync bool) (err error) {
+	concurrentSolver, err := pos.NewConcurrentDiskSolver(image, image, 0)
+	if err != nil {
+		return err
+	}
+
+	concurrentSolver.BlockSize = blockSize
+	concurrentSolver.Workers = workers
+
+	if h != nil {
+		concurrentSolver.Offset = pos.ImageHeaderSize
+	}
+
+	err = concurrentSolver.Prepare(puzzle)
+	if err != nil {
+		return err
+	}
+
+	if h != nil {
+		err = image.Sync()
+		if err != nil {
+			return err
+		}
+
+		h.Prepared = puzzle.Claim
+
+		err = h.Write(image)
+		if err != nil {
+			return err
+		}
+	}
+
+	if sync {
+		return image.Sync()
+	}
+
+	return nil
+}
+
 func init() {
 	diskCmd.AddCommand(diskPrepareCmd)
+
+	diskPrepareCmd.PersistentFlags().Bool("header", true, "Write a header identifying the puzzle to the image")
+	diskPrepareCmd.PersistentFlags().Bool("resume", false, "Resume preparing from the last checkpoint in the image header")
+	diskPrepareCmd.PersistentFlags().Int("block-size", pos.DefaultBlockSize, "Size of the blocks written to the image (bytes)")
+	diskPrepareCmd.PersistentFlags().Bool("double-buffer", false, "Generate the next block while the previous one is written")
+	diskPrepareCmd.PersistentFlags().Int64("checkpoint-interval", 1024*1024*1024, "Bytes prepared between checkpoints (requires a header)")
+	diskPrepareCmd.PersistentFlags().Bool("direct", false, "Write the image with direct I/O bypassing the page cache (Linux only)")
+	diskPrepareCmd.PersistentFlags().Int("workers", 1, "Number of workers generating the image in parallel (requires a segmented or seekable PRNG)")
+	diskPrepareCmd.PersistentFlags().Bool("sync", true, "Sync the image to durable storage before exiting")
+	diskPrepareCmd.PersistentFlags().String("layout", pos.LayoutStripe, "Layout of a sharded image (concat, stripe)")
+	diskPrepareCmd.PersistentFlags().Int64("stripe-size", pos.DefaultBlockSize, "Size of the stripes of a sharded image (bytes)")
 }
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/calebcase/pos"
+	"github.com/spf13/cobra"
+)
+
+// prepareSharded prepares the puzzle across the given image paths and writes
+// the manifest describing the layout.
+func prepareSharded(cmd *cobra.Command, paths []string, puzzle *pos.Puzzle) (err error) {
+	manifestPath, err := cmd.Flags().GetString("manifest")
+	if err != nil {
+		return err
+	}
+
+	if manifestPath == "" {
+		return UsageError{errors.New("--manifest is required with multiple images")}
+	}
+
+	if len(paths) == 0 {
+		return UsageError{errors.New("no image given")}
+	}
+
+	layout, err := cmd.Flags().GetString("layout")
+	if err != nil {
+		return err
+	}
+
+	stripeSize, err := cmd.Flags().GetInt64("stripe-size")
+	if err != nil {
+		return err
+	}
+
+	blockSize, err := cmd.Flags().GetInt("block-size")
+	if err != nil {
+		return err
+	}
+
+	sync, err := cmd.Flags().GetBool("sync")
+	if err != nil {
+		return err
+	}
+
+	manifest, err := pos.NewManifest(puzzle, layout, stripeSize, paths)
+	if err != nil {
+		return err
+	}
+
+	files := make([]*os.File, len(paths), len(paths))
+	shards := make([]pos.ShardImage, len(paths), len(paths))
+
+	for i, path := range paths {
+		files[i], err = openImage(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+		if err != nil {
+			return err
+		}
+		defer files[i].Close()
+
+		shards[i] = files[i]
+	}
+
+	shardedSolver, err := pos.NewShardedDiskSolver(manifest, shards)
+	if err != nil {
+		return err
+	}
+
+	shardedSolver.BlockSize = blockSize
+
+	err = shardedSolver.Prepare(puzzle)
+	if err != nil {
+		return err
+	}
+
+	if sync {
+		for _, f := range files {
+			err = f.Sync()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// The manifest is written last so that it only exists for a complete
+	// image.
+	output, err := os.Create(manifestPath)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	enc := json.NewEncoder(output)
+	enc.SetIndent("", "  ")
+
+	err = enc.Encode(manifest)
+	if err != nil {
+		return err
+	}
+
+	if sync {
+		return output.Sync()
+	}
+
+	return nil
+}
+
+// openSharded returns a solver for the sharded image described by the
+// manifest. If no paths are given the shards are opened from the paths in the
+// manifest. Otherwise the paths must match the manifest in order. The returned
+// files must be closed by the caller.
+func openSharded(cmd *cobra.Command, paths []string, puzzle *pos.Puzzle) (solver *pos.ShardedDiskSolver, files []*os.File, err error) {
+	manifestPath, err := cmd.Flags().GetString("manifest")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if manifestPath == "" {
+		return nil, nil, UsageError{errors.New("--manifest is required with multiple images")}
+	}
+
+	input, err := openImage(manifestPath, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer input.Close()
+
+	var manifest pos.Manifest
+
+	err = json.NewDecoder(input).Decode(&manifest)
+	if err != nil {
+		return nil, nil, ImageError{manifestPath, err}
+	}
+
+	err = manifest.Check(puzzle)
+	if err != nil {
+		return nil, nil, ImageError{manifestPath, err}
+	}
+
+	if len(paths) == 0 {
+		for _, shard := range manifest.Shards {
+			paths = append(paths, shard.Path)
+		}
+	}
+
+	if len(paths) != len(manifest.Shards) {
+		return nil, nil, UsageError{fmt.Errorf("%d images given for the %d shards in the manifest", len(paths), len(manifest.Shards))}
+	}
+
+	for i, path := range paths {
+		if filepath.Clean(path) != filepath.Clean(manifest.Shards[i].Path) {
+			return nil, nil, UsageError{fmt.Errorf("image %q does not match shard %d %q in the manifest", path, i, manifest.Shards[i].Path)}
+		}
+	}
+
+	shards := make([]pos.ShardImage, 0, len(paths))
+
+	for _, path := range paths {
+		f, err := openImage(path, os.O_RDONLY, 0)
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+
+			return nil, nil, err
+		}
+
+		files = append(files, f)
+		shards = append(shards, f)
+	}
+
+	solver, err = pos.NewShardedDiskSolver(&manifest, shards)
+	if err == nil {
+		err = solver.CheckShards()
+		if i, ok := err.(pos.ShardMismatchError); ok {
+			err = ImageError{paths[i], err}
+		}
+	}
+	if err != nil {
+		for _, f := range files {
+			f.Close()
+		}
+
+		return nil, nil, err
+	}
+
+	return solver, files, nil
+}
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 
@@ -12,74 +11,98 @@ import (
 var diskSolveCmd = &cobra.Command{
 	Use:   "solve",
 	Short: "Solve a puzzle with a disk solver",
-	Run: func(cmd *cobra.Command, args []string) {
-		var err error
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := readPuzzle(cmd)
+		if err != nil {
+			return err
+		}
+
+		paths, sharded, err := imagePaths(cmd)
+		if err != nil {
+			return err
+		}
 
-		input := os.Stdin
-		if cmd.Flags().Changed("puzzle") {
-			path, err := cmd.Flags().GetString("puzzle")
+		var diskSolver pos.Solver
+
+		if sharded {
+			shardedSolver, files, err := openSharded(cmd, paths, p)
 			if err != nil {
-				panic(err)
+				return err
+			}
+			for _, f := range files {
+				defer f.Close()
 			}
 
-			if path != "-" {
-				input, err = os.Open(path)
-				if err != nil {
-					panic(err)
-				}
-				defer input.Close()
+			diskSolver = shardedSolver
+		} else {
+			image, err := openImage(paths[0], os.O_RDONLY, 0)
+			if err != nil {
+				return err
 			}
-		}
+			defer image.Close()
 
-		dec := json.NewDecoder(input)
+			offset, err := imageOffset(image, p)
+			if err != nil {
+				return err
+			}
 
-		var p puzzle
+			useMmap, err := cmd.Flags().GetBool("mmap")
+			if err != nil {
+				return err
+			}
 
-		err = dec.Decode(&p)
-		if err != nil {
-			panic(err)
-		}
+			inFlight, err := cmd.Flags().GetInt("in-flight")
+			if err != nil {
+				return err
+			}
 
-		path, err := cmd.Flags().GetString("image")
-		if err != nil {
-			panic(err)
-		}
+			switch {
+			case useMmap:
+				mmapSolver, err := pos.NewMmapDiskSolver(image)
+				if err != nil {
+					return err
+				}
+				defer mmapSolver.Close()
 
-		image, err := os.Open(path)
-		if err != nil {
-			panic(err)
-		}
-		defer image.Close()
+				mmapSolver.Offset = offset
+				diskSolver = mmapSolver
+			case inFlight > 0:
+				concurrentSolver, err := pos.NewConcurrentDiskSolver(image, nil, inFlight)
+				if err != nil {
+					return err
+				}
 
-		diskSolver, err := pos.NewDiskSolver(image)
-		if err != nil {
-			panic(err)
-		}
+				concurrentSolver.Offset = offset
+				diskSolver = concurrentSolver
+			default:
+				seekSolver, err := pos.NewDiskSolver(image)
+				if err != nil {
+					return err
+				}
 
-		puz := &pos.Puzzle{
-			Claim:         p.Claim,
-			PRNG:          p.PRNG,
-			PreseedRounds: p.PreseedRounds,
-			IndexSize:     p.IndexSize,
-			SolutionSize:  p.SolutionSize,
+				seekSolver.Offset = offset
+				diskSolver = seekSolver
+			}
 		}
 
 		preseedIndices, err := cmd.Flags().GetInt64Slice("preseed-indices")
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		mask, err := cmd.Flags().GetBytesBase64("mask")
 		if err != nil {
-			panic(err)
+			return err
 		}
 
-		solution, err := diskSolver.Solve(puz, preseedIndices, mask)
+		solution, err := diskSolver.Solve(p, preseedIndices, mask)
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		fmt.Printf("Solution: %x\n", solution)
+
+		return nil
 	},
 }
 
@@ -91,4 +114,7 @@ func init() {
 
 	diskSolveCmd.PersistentFlags().BytesBase64("mask", []byte{}, "A base64 encoded mask")
 	cobra.MarkFlagRequired(diskSolveCmd.PersistentFlags(), "mask")
+
+	diskSolveCmd.PersistentFlags().Bool("mmap", false, "Read the image through a memory mapping")
+	diskSolveCmd.PersistentFlags().Int("in-flight", 0, "Number of concurrent reads (0 reads serially)")
 }
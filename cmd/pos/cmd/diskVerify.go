@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/calebcase/pos"
+	"github.com/spf13/cobra"
+)
+
+var diskVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify a prepared image against its puzzle",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := readPuzzle(cmd)
+		if err != nil {
+			return err
+		}
+
+		path, err := imagePath(cmd)
+		if err != nil {
+			return err
+		}
+
+		image, err := openImage(path, os.O_RDONLY, 0)
+		if err != nil {
+			return err
+		}
+		defer image.Close()
+
+		offset, err := imageOffset(image, p)
+		if err != nil {
+			return err
+		}
+
+		diskSolver, err := pos.NewDiskSolver(image)
+		if err != nil {
+			return err
+		}
+
+		diskSolver.Offset = offset
+
+		diskSolver.BlockSize, err = cmd.Flags().GetInt("block-size")
+		if err != nil {
+			return err
+		}
+
+		var samples int64
+
+		if cmd.Flags().Changed("confidence") {
+			confidence, err := cmd.Flags().GetFloat64("confidence")
+			if err != nil {
+				return err
+			}
+
+			fraction, err := cmd.Flags().GetFloat64("bad-fraction")
+			if err != nil {
+				return err
+			}
+
+			samples = pos.SampleSize(confidence, fraction)
+		}
+
+		report, err := diskSolver.Check(p, samples)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Rate: %.2f MiB/s\n", report.Rate()/1024/1024)
+
+		err = json.NewEncoder(os.Stdout).Encode(report)
+		if err != nil {
+			return err
+		}
+
+		if report.BadBlocks > 0 {
+			return BadBlocksError(report.BadBlocks)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	diskCmd.AddCommand(diskVerifyCmd)
+
+	diskVerifyCmd.PersistentFlags().Int("block-size", pos.DefaultBlockSize, "Size of the blocks compared (bytes)")
+	diskVerifyCmd.PersistentFlags().Float64("confidence", 0, "Sample blocks to detect bad blocks with this confidence (default checks every block)")
+	diskVerifyCmd.PersistentFlags().Float64("bad-fraction", 0.001, "Smallest fraction of bad blocks to detect when sampling")
+}
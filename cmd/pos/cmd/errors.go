@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"crypto/aes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+
+	"github.com/calebcase/pos"
+	"github.com/calebcase/pos/lib/aesprng"
+	"github.com/calebcase/pos/lib/chachaprng"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes returned by pos. Each category of error has its own code so
+// that automation can act on failures without parsing messages.
+const (
+	ExitOK       = 0 // The command succeeded.
+	ExitFailure  = 1 // The command failed for a reason not listed below.
+	ExitUsage    = 2 // The flags or arguments were invalid.
+	ExitPuzzle   = 3 // The puzzle could not be read or is invalid.
+	ExitSeed     = 4 // The puzzle's seed has the wrong size for its PRNG.
+	ExitImage    = 5 // The image is missing or does not match the puzzle.
+	ExitIO       = 6 // Reading or writing a file or connection failed.
+	ExitRejected = 7 // The image or proof failed verification.
+)
+
+// UsageError is returned when the flags or arguments are invalid.
+type UsageError struct {
+	Err error
+}
+
+func (e UsageError) Error() string { return e.Err.Error() }
+func (e UsageError) Unwrap() error { return e.Err }
+
+// PuzzleError is returned when the puzzle cannot be read or is invalid.
+type PuzzleError struct {
+	Err error
+}
+
+func (e PuzzleError) Error() string { return "Invalid puzzle: " + e.Err.Error() }
+func (e PuzzleError) Unwrap() error { return e.Err }
+
+// SeedError is returned when the puzzle's seed has the wrong size for its
+// PRNG.
+type SeedError struct {
+	Err error
+}
+
+func (e SeedError) Error() string { return "Invalid seed: " + e.Err.Error() }
+func (e SeedError) Unwrap() error { return e.Err }
+
+// ImageError is returned when an image is missing or does not match the
+// puzzle.
+type ImageError struct {
+	Path string
+	Err  error
+}
+
+func (e ImageError) Error() string { return fmt.Sprintf("Image %s: %s", e.Path, e.Err) }
+func (e ImageError) Unwrap() error { return e.Err }
+
+// IOError is returned when reading or writing fails.
+type IOError struct {
+	Err error
+}
+
+func (e IOError) Error() string { return "I/O failure: " + e.Err.Error() }
+func (e IOError) Unwrap() error { return e.Err }
+
+// BadBlocksError is returned when verification finds bad blocks in an image.
+type BadBlocksError int64
+
+func (e BadBlocksError) Error() string {
+	return fmt.Sprintf("Image has %d bad blocks", int64(e))
+}
+
+// RejectedError is returned when a proof's solution is wrong. It is the
+// position of the first mismatched byte.
+type RejectedError int
+
+func (e RejectedError) Error() string {
+	return fmt.Sprintf("Proof rejected (solution mismatch at byte %d)", int(e))
+}
+
+// puzzleError returns err as a SeedError if it was caused by the seed and as a
+// PuzzleError otherwise.
+func puzzleError(err error) error {
+	switch err.(type) {
+	case aesprng.SeedSizeError, chachaprng.SeedSizeError, aes.KeySizeError, pos.EncodedSeedSizeError:
+		return SeedError{err}
+	}
+
+	return PuzzleError{err}
+}
+
+// classify returns err as one of the error types above. Errors that are not
+// recognized are returned as is.
+func classify(err error) error {
+	switch err.(type) {
+	case UsageError, PuzzleError, SeedError, ImageError, IOError, BadBlocksError, RejectedError:
+		return err
+	case aesprng.SeedSizeError, chachaprng.SeedSizeError, aes.KeySizeError, pos.EncodedSeedSizeError:
+		return SeedError{err}
+	case pos.PuzzleVersionError, pos.PuzzleEncodingError, pos.SeekablePRNGError:
+		return PuzzleError{err}
+	case pos.UnknownPRNGError, pos.ClaimError, pos.PreseedRoundsError, pos.IndexSizeError, pos.SolutionSizeError, pos.BiasError:
+		return PuzzleError{err}
+	case pos.MaskSizeError, pos.PreseedSizeError, pos.IndexRangeError:
+		return UsageError{err}
+	case *os.PathError, *os.SyscallError, *net.OpError, syscall.Errno:
+		return IOError{err}
+	}
+
+	if err == pos.ErrNoPRNG {
+		return PuzzleError{err}
+	}
+
+	if err == pos.ErrNotSegmented {
+		return UsageError{err}
+	}
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF || err == io.ErrShortWrite {
+		return IOError{err}
+	}
+
+	return err
+}
+
+// exitCode returns the exit code for err.
+func exitCode(err error) (code int, kind string) {
+	switch err.(type) {
+	case nil:
+		return ExitOK, ""
+	case UsageError:
+		return ExitUsage, "usage"
+	case PuzzleError:
+		return ExitPuzzle, "puzzle"
+	case SeedError:
+		return ExitSeed, "seed"
+	case ImageError:
+		return ExitImage, "image"
+	case IOError:
+		return ExitIO, "io"
+	case BadBlocksError, RejectedError:
+		return ExitRejected, "rejected"
+	}
+
+	return ExitFailure, "failure"
+}
+
+// errorOutput is the object written for an error with --output json.
+type errorOutput struct {
+	Error struct {
+		Kind    string `json:"kind"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// report writes err in the format selected by the output flag and returns the
+// exit code.
+func report(cmd *cobra.Command, err error) int {
+	code, kind := exitCode(err)
+
+	format, ferr := cmd.Flags().GetString("output")
+	if ferr != nil || format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+
+		if code == ExitUsage {
+			fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", cmd.CommandPath())
+		}
+
+		return code
+	}
+
+	var out errorOutput
+
+	out.Error.Kind = kind
+	out.Error.Code = code
+	out.Error.Message = err.Error()
+
+	json.NewEncoder(os.Stdout).Encode(out)
+
+	return code
+}
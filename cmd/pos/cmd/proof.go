@@ -0,0 +1,12 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var proofCmd = &cobra.Command{
+	Use:   "proof",
+	Short: "Non-interactive proof commands",
+}
+
+func init() {
+	rootCmd.AddCommand(proofCmd)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/calebcase/pos"
+	"github.com/spf13/cobra"
+)
+
+var proofCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a proof from a prepared image and a beacon",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := readPuzzle(cmd)
+		if err != nil {
+			return err
+		}
+
+		beacon, err := cmd.Flags().GetString("beacon")
+		if err != nil {
+			return err
+		}
+
+		path, err := cmd.Flags().GetString("image")
+		if err != nil {
+			return err
+		}
+
+		image, err := openImage(path, os.O_RDONLY, 0)
+		if err != nil {
+			return err
+		}
+		defer image.Close()
+
+		offset, err := imageOffset(image, p)
+		if err != nil {
+			return err
+		}
+
+		diskSolver, err := pos.NewDiskSolver(image)
+		if err != nil {
+			return err
+		}
+
+		diskSolver.Offset = offset
+
+		proof, err := pos.Prove(diskSolver, p, []byte(beacon))
+		if err != nil {
+			return err
+		}
+
+		return json.NewEncoder(os.Stdout).Encode(proof)
+	},
+}
+
+func init() {
+	proofCmd.AddCommand(proofCreateCmd)
+
+	proofCreateCmd.PersistentFlags().StringP("puzzle", "p", "", "Path to a puzzle config")
+
+	proofCreateCmd.PersistentFlags().StringP("image", "i", "", "Path to a image file")
+	cobra.MarkFlagRequired(proofCreateCmd.PersistentFlags(), "image")
+
+	proofCreateCmd.PersistentFlags().String("beacon", "", "Public value the challenge is derived from (e.g. a block hash)")
+	cobra.MarkFlagRequired(proofCreateCmd.PersistentFlags(), "beacon")
+}
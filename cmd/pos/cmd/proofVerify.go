@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/calebcase/pos"
+	"github.com/spf13/cobra"
+)
+
+// proofVerdict is the outcome of verifying a proof.
+type proofVerdict struct {
+	Accepted bool   `json:"accepted"`  // True if the solution was correct.
+	Mismatch int    `json:"mismatch"`  // The position of the first mismatched solution byte or -1.
+	PuzzleID string `json:"puzzle_id"` // The ID of the proof's puzzle.
+}
+
+var proofVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify a proof",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input := os.Stdin
+		if cmd.Flags().Changed("proof") {
+			path, err := cmd.Flags().GetString("proof")
+			if err != nil {
+				return err
+			}
+
+			if path != "-" {
+				input, err = os.Open(path)
+				if err != nil {
+					return IOError{err}
+				}
+				defer input.Close()
+			}
+		}
+
+		var proof pos.Proof
+
+		err := json.NewDecoder(input).Decode(&proof)
+		if err != nil {
+			return puzzleError(err)
+		}
+
+		if proof.Puzzle == nil {
+			return PuzzleError{pos.ErrNoPuzzle}
+		}
+
+		max, err := maxIndexBias(cmd)
+		if err != nil {
+			return err
+		}
+
+		err = proof.Puzzle.ValidateBias(max)
+		if err != nil {
+			return PuzzleError{err}
+		}
+
+		id, err := proof.Puzzle.ID()
+		if err != nil {
+			return PuzzleError{err}
+		}
+
+		verifier, err := pos.NewVerifier()
+		if err != nil {
+			return err
+		}
+
+		ok, err := verifier.VerifyProof(&proof)
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(os.Stdout).Encode(proofVerdict{
+			Accepted: ok,
+			Mismatch: verifier.Mismatch,
+			PuzzleID: id.String(),
+		})
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return RejectedError(verifier.Mismatch)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	proofCmd.AddCommand(proofVerifyCmd)
+
+	proofVerifyCmd.PersistentFlags().String("proof", "", "Path to a proof")
+	addMaxIndexBiasFlag(proofVerifyCmd)
+}
@@ -0,0 +1,12 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var proverCmd = &cobra.Command{
+	Use:   "prover",
+	Short: "Network prover commands",
+}
+
+func init() {
+	rootCmd.AddCommand(proverCmd)
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/calebcase/pos"
+	"github.com/calebcase/pos/lib/protocol"
+	"github.com/spf13/cobra"
+)
+
+var proverServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve challengers over TCP with a disk solver",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, err := cmd.Flags().GetString("addr")
+		if err != nil {
+			return err
+		}
+
+		claim, err := cmd.Flags().GetInt64("claim")
+		if err != nil {
+			return err
+		}
+
+		path, err := cmd.Flags().GetString("image")
+		if err != nil {
+			return err
+		}
+
+		interval, err := cmd.Flags().GetInt64("checkpoint-interval")
+		if err != nil {
+			return err
+		}
+
+		image, err := openImage(path, os.O_RDWR|os.O_CREATE, 0666)
+		if err != nil {
+			return err
+		}
+		defer image.Close()
+
+		solver := &imageSolver{
+			image:    image,
+			interval: interval,
+		}
+
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+		defer listener.Close()
+
+		fmt.Fprintf(os.Stderr, "Listening on %s\n", listener.Addr())
+
+		// Challengers are served one at a time since they share the image.
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return err
+			}
+
+			verdict, err := serveProver(conn, claim, solver)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", conn.RemoteAddr(), err)
+
+				continue
+			}
+
+			err = json.NewEncoder(os.Stdout).Encode(verdict)
+			if err != nil {
+				return err
+			}
+		}
+	},
+}
+
+func serveProver(conn net.Conn, claim int64, solver pos.Solver) (verdict *pos.Verdict, err error) {
+	defer conn.Close()
+
+	prover, err := protocol.NewProver(claim, solver)
+	if err != nil {
+		return nil, err
+	}
+
+	return prover.Run(conn)
+}
+
+// imageSolver prepares an image with a header once and then solves from it.
+// A puzzle that does not match the header is refused so that the image is
+// never overwritten.
+type imageSolver struct {
+	image    *os.File
+	interval int64
+}
+
+var _ pos.Solver = (*imageSolver)(nil)
+
+// header reads the image header and checks it against the puzzle.
+func (s *imageSolver) header(puzzle *pos.Puzzle) (h *pos.ImageHeader, err error) {
+	h, err = pos.ReadImageHeader(s.image)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.Check(puzzle)
+	if err != nil {
+		return nil, ImageError{s.image.Name(), err}
+	}
+
+	return h, nil
+}
+
+func (s *imageSolver) Prepare(puzzle *pos.Puzzle) (err error) {
+	h, err := s.header(puzzle)
+	if err == pos.ErrNoImageHeader {
+		info, err := s.image.Stat()
+		if err != nil {
+			return IOError{err}
+		}
+
+		if info.Size() != 0 {
+			return ImageError{s.image.Name(), errors.New("image has no header and will not be overwritten")}
+		}
+
+		h, err = pos.NewImageHeader(puzzle)
+		if err != nil {
+			return err
+		}
+
+		err = h.Write(s.image)
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if h.Complete() == nil {
+		return nil
+	}
+
+	diskSolver, err := pos.NewDiskSolver(s.image)
+	if err != nil {
+		return err
+	}
+
+	diskSolver.Offset = pos.ImageHeaderSize
+	diskSolver.CheckpointInterval = s.interval
+	diskSolver.Checkpoint = headerCheckpoint(s.image, h)
+
+	return diskSolver.Resume(puzzle, h.Prepared)
+}
+
+func (s *imageSolver) Solve(puzzle *pos.Puzzle, preseedIndices []int64, mask []byte) (solution []byte, err error) {
+	h, err := s.header(puzzle)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.Complete()
+	if err != nil {
+		return nil, ImageError{s.image.Name(), err}
+	}
+
+	diskSolver, err := pos.NewDiskSolver(s.image)
+	if err != nil {
+		return nil, err
+	}
+
+	diskSolver.Offset = pos.ImageHeaderSize
+
+	return diskSolver.Solve(puzzle, preseedIndices, mask)
+}
+
+func init() {
+	proverCmd.AddCommand(proverServeCmd)
+
+	proverServeCmd.PersistentFlags().String("addr", ":7470", "Address to listen on")
+
+	proverServeCmd.PersistentFlags().Int64P("claim", "c", 0, "Size of the claimed storage (bytes)")
+	cobra.MarkFlagRequired(proverServeCmd.PersistentFlags(), "claim")
+
+	proverServeCmd.PersistentFlags().StringP("image", "i", "", "Path to a image file")
+	cobra.MarkFlagRequired(proverServeCmd.PersistentFlags(), "image")
+
+	proverServeCmd.PersistentFlags().Int64("checkpoint-interval", 1024*1024*1024, "Bytes prepared between checkpoints")
+}
@@ -1,9 +1,19 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/calebcase/pos"
-	"github.com/calebcase/pos/lib/aesprng"
 	"github.com/spf13/cobra"
+
+	// Register the PRNG types available to puzzles.
+	_ "github.com/calebcase/pos/lib/aesctrprng"
+	_ "github.com/calebcase/pos/lib/aesprng"
+	_ "github.com/calebcase/pos/lib/aessegprng"
+	_ "github.com/calebcase/pos/lib/chachaprng"
 )
 
 var puzzleCmd = &cobra.Command{
@@ -11,10 +21,157 @@ var puzzleCmd = &cobra.Command{
 	Short: "Puzzle commands",
 }
 
-type puzzle struct {
-	pos.Puzzle
+// addPuzzleFlags adds the flags used by newPuzzle to cmd.
+func addPuzzleFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("prng", "aes", "PRNG type ("+strings.Join(pos.PRNGTypes(), ", ")+")")
+	cmd.PersistentFlags().BytesBase64("seed", []byte{}, "A base64 encoded seed (default random)")
+
+	cmd.PersistentFlags().Int64("index-size", 64, "Size of the index (bytes)")
+	cmd.PersistentFlags().Int64("solution-size", 10, "Size of the solution (bytes)")
+
+	cmd.PersistentFlags().Int64("preseed-rounds", 0, "Number of preseed rounds")
+	cmd.PersistentFlags().Float64("pr-est-rate", 1024*1024*1024*10, "Rate of PRNG generation (bytes per second)")
+	cmd.PersistentFlags().Float64("pr-est-scale", 2, "Desired time scale (seconds)")
+
+	addMaxIndexBiasFlag(cmd)
+}
+
+// addMaxIndexBiasFlag adds the flag read by maxIndexBias to cmd.
+func addMaxIndexBiasFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().Float64("max-index-bias", pos.DefaultMaxIndexBias, "Largest accepted index bias (see Puzzle.Bias)")
+}
+
+// maxIndexBias returns the value of the flag added by addMaxIndexBiasFlag.
+func maxIndexBias(cmd *cobra.Command) (float64, error) {
+	max, err := cmd.Flags().GetFloat64("max-index-bias")
+	if err != nil {
+		return 0, err
+	}
+
+	if max < 0 {
+		return 0, UsageError{fmt.Errorf("invalid max index bias %g", max)}
+	}
+
+	return max, nil
+}
+
+// newPuzzle creates a puzzle for the given claim from the flags added by
+// addPuzzleFlags. The puzzle is checked with ValidateBias.
+func newPuzzle(cmd *cobra.Command, claim int64) (*pos.Puzzle, error) {
+	prngType, err := cmd.Flags().GetString("prng")
+	if err != nil {
+		return nil, err
+	}
+
+	var seed []byte
+
+	if cmd.Flags().Changed("seed") {
+		seed, err = cmd.Flags().GetBytesBase64("seed")
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		size, err := pos.PRNGSeedSize(prngType)
+		if err != nil {
+			return nil, err
+		}
+
+		seed, err = pos.NewRandomBytes(size)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	prng, err := pos.NewPRNG(prngType, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	indexSize, err := cmd.Flags().GetInt64("index-size")
+	if err != nil {
+		return nil, err
+	}
+
+	solutionSize, err := cmd.Flags().GetInt64("solution-size")
+	if err != nil {
+		return nil, err
+	}
+
+	var preseedRounds int64
+
+	if cmd.Flags().Changed("preseed-rounds") {
+		preseedRounds, err = cmd.Flags().GetInt64("preseed-rounds")
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		rate, err := cmd.Flags().GetFloat64("pr-est-rate")
+		if err != nil {
+			return nil, err
+		}
+
+		scale, err := cmd.Flags().GetFloat64("pr-est-scale")
+		if err != nil {
+			return nil, err
+		}
+
+		preseedRounds = pos.EstimatePreseedRounds(claim, rate, scale)
+	}
+
+	max, err := maxIndexBias(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	puzzle := &pos.Puzzle{
+		Claim:         claim,
+		PRNG:          prng,
+		PreseedRounds: preseedRounds,
+		IndexSize:     indexSize,
+		SolutionSize:  solutionSize,
+	}
+
+	err = puzzle.ValidateBias(max)
+	if err != nil {
+		return nil, PuzzleError{err}
+	}
+
+	return puzzle, nil
+}
+
+// readPuzzle decodes the puzzle from the path given by the puzzle flag or
+// from stdin and checks it with Check. The index bias is left to whoever
+// created the puzzle.
+func readPuzzle(cmd *cobra.Command) (*pos.Puzzle, error) {
+	input := os.Stdin
+	if cmd.Flags().Changed("puzzle") {
+		path, err := cmd.Flags().GetString("puzzle")
+		if err != nil {
+			return nil, err
+		}
+
+		if path != "-" {
+			input, err = os.Open(path)
+			if err != nil {
+				return nil, PuzzleError{err}
+			}
+			defer input.Close()
+		}
+	}
+
+	var p pos.Puzzle
+
+	err := json.NewDecoder(input).Decode(&p)
+	if err != nil {
+		return nil, puzzleError(err)
+	}
+
+	err = p.Check()
+	if err != nil {
+		return nil, PuzzleError{err}
+	}
 
-	PRNG *aesprng.State `json:"prng"`
+	return &p, nil
 }
 
 func init() {
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var puzzleIDCmd = &cobra.Command{
+	Use:   "id",
+	Short: "Print the ID of a puzzle",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := readPuzzle(cmd)
+		if err != nil {
+			return err
+		}
+
+		id, err := p.ID()
+		if err != nil {
+			return PuzzleError{err}
+		}
+
+		fmt.Println(id)
+
+		return nil
+	},
+}
+
+func init() {
+	puzzleCmd.AddCommand(puzzleIDCmd)
+
+	puzzleIDCmd.PersistentFlags().StringP("puzzle", "p", "", "Path to a puzzle config")
+}
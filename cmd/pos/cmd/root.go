@@ -9,14 +9,64 @@ import (
 
 var cfgFile string
 
+// started is set once a command begins to run. Errors before then come from
+// validating the flags and arguments and are usage errors.
+var started bool
+
 var rootCmd = &cobra.Command{
 	Use:   "pos",
 	Short: "Proof of Space",
+
+	SilenceErrors: true,
+	SilenceUsage:  true,
+
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		format, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		if format != "text" && format != "json" {
+			return UsageError{fmt.Errorf("Invalid output format %q", format)}
+		}
+
+		return nil
+	},
 }
 
+// Execute runs the command and exits with the code for its error, see
+// ExitOK and the codes that follow it.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	markStarted(rootCmd)
+
+	cmd, err := rootCmd.ExecuteC()
+	if err == nil {
+		return
+	}
+
+	if !started {
+		err = UsageError{err}
 	}
+
+	os.Exit(report(cmd, classify(err)))
+}
+
+// markStarted wraps the commands in the tree so that they set started when
+// they run.
+func markStarted(c *cobra.Command) {
+	if run := c.RunE; run != nil {
+		c.RunE = func(cmd *cobra.Command, args []string) error {
+			started = true
+
+			return run(cmd, args)
+		}
+	}
+
+	for _, sub := range c.Commands() {
+		markStarted(sub)
+	}
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringP("output", "o", "text", "Format of errors (text, json)")
 }
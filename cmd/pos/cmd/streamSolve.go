@@ -1,9 +1,7 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/calebcase/pos"
 	"github.com/spf13/cobra"
@@ -12,63 +10,35 @@ import (
 var streamSolveCmd = &cobra.Command{
 	Use:   "solve",
 	Short: "Solve a puzzle with a stream solver",
-	Run: func(cmd *cobra.Command, args []string) {
-		var err error
-
-		input := os.Stdin
-		if cmd.Flags().Changed("puzzle") {
-			path, err := cmd.Flags().GetString("puzzle")
-			if err != nil {
-				panic(err)
-			}
-
-			if path != "-" {
-				input, err = os.Open(path)
-				if err != nil {
-					panic(err)
-				}
-				defer input.Close()
-			}
-		}
-
-		dec := json.NewDecoder(input)
-
-		var p puzzle
-
-		err = dec.Decode(&p)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := readPuzzle(cmd)
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		streamSolver, err := pos.NewStreamSolver()
 		if err != nil {
-			panic(err)
-		}
-
-		puz := &pos.Puzzle{
-			Claim:         p.Claim,
-			PRNG:          p.PRNG,
-			PreseedRounds: p.PreseedRounds,
-			IndexSize:     p.IndexSize,
-			SolutionSize:  p.SolutionSize,
+			return err
 		}
 
 		preseedIndices, err := cmd.Flags().GetInt64Slice("preseed-indices")
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		mask, err := cmd.Flags().GetBytesBase64("mask")
 		if err != nil {
-			panic(err)
+			return err
 		}
 
-		solution, err := streamSolver.Solve(puz, preseedIndices, mask)
+		solution, err := streamSolver.Solve(p, preseedIndices, mask)
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		fmt.Printf("Solution: %x\n", solution)
+
+		return nil
 	},
 }
 
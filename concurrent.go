@@ -0,0 +1,254 @@
+package pos
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"sync"
+)
+
+// ErrNoWriter is returned when preparing with a solver that was not given a
+// writer.
+var ErrNoWriter = errors.New("no writer to prepare with")
+
+// ConcurrentDiskSolver is a disk solver that reads through an io.ReaderAt.
+// Without a shared file offset the reads for a set of indices are
+// independent, so they are sorted, coalesced into ranges and issued
+// concurrently with a bounded number in flight. This keeps deep queue
+// storage busy.
+//
+// Prepare writes through an io.WriterAt. With more than one worker the claim
+// is split into ranges that are generated and written by Workers goroutines in
+// parallel. This requires the puzzle's PRNG to be a SegmentedPRNG, in which
+// case the ranges are its segments, or a SeekablePRNG. The image is byte
+// identical to one prepared by a single worker.
+type ConcurrentDiskSolver struct {
+	InFlight  int   // The maximum number of concurrent reads.
+	Gap       int64 // Indices at most this many bytes apart are read together.
+	Offset    int64 // The offset of the claimed space in the image.
+	BlockSize int   // The size in bytes of the blocks written by Prepare.
+	Workers   int   // The number of workers used by Prepare.
+
+	r io.ReaderAt
+	w io.WriterAt
+}
+
+var _ Solver = (*ConcurrentDiskSolver)(nil)
+
+// NewConcurrentDiskSolver returns a solver reading from r. The writer w is
+// only used by Prepare and may be nil if the image is already prepared. If
+// inFlight is less than 1 a default of 32 is used.
+func NewConcurrentDiskSolver(r io.ReaderAt, w io.WriterAt, inFlight int) (*ConcurrentDiskSolver, error) {
+	if inFlight < 1 {
+		inFlight = 32
+	}
+
+	return &ConcurrentDiskSolver{
+		InFlight:  inFlight,
+		Gap:       4096,
+		BlockSize: DefaultBlockSize,
+		Workers:   1,
+
+		r: r,
+		w: w,
+	}, nil
+}
+
+func (s *ConcurrentDiskSolver) Prepare(puzzle *Puzzle) (err error) {
+	if s.w == nil {
+		return ErrNoWriter
+	}
+
+	if s.BlockSize <= 0 {
+		return BlockSizeError(s.BlockSize)
+	}
+
+	prng, err := puzzle.PRNG.Clone()
+	if err != nil {
+		return err
+	}
+
+	if s.Workers < 2 {
+		return s.prepare(prng, 0, puzzle.Claim)
+	}
+
+	// Each range of the claim is generated by a PRNG started at its
+	// beginning.
+	var size int64
+	var start func(k int64) (PRNG, error)
+
+	switch p := prng.(type) {
+	case SegmentedPRNG:
+		size = p.SegmentSize()
+		start = p.Segment
+	case SeekablePRNG:
+		// Split the claim into one range per worker aligned to the block
+		// size.
+		blockSize := int64(s.BlockSize)
+		size = (puzzle.Claim + int64(s.Workers) - 1) / int64(s.Workers)
+		size = (size + blockSize - 1) / blockSize * blockSize
+
+		start = func(k int64) (PRNG, error) {
+			prng, err := puzzle.PRNG.Clone()
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = prng.(SeekablePRNG).Seek(k*size, io.SeekStart)
+			if err != nil {
+				return nil, err
+			}
+
+			return prng, nil
+		}
+	default:
+		return ErrNotSegmented
+	}
+
+	ranges := make(chan int64)
+	errs := make([]error, s.Workers, s.Workers)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < s.Workers; w++ {
+		wg.Add(1)
+
+		go func(w int) {
+			defer wg.Done()
+
+			for k := range ranges {
+				if errs[w] != nil {
+					continue
+				}
+
+				prng, err := start(k)
+				if err != nil {
+					errs[w] = err
+
+					continue
+				}
+
+				end := (k + 1) * size
+				if end > puzzle.Claim {
+					end = puzzle.Claim
+				}
+
+				errs[w] = s.prepare(prng, k*size, end)
+			}
+		}(w)
+	}
+
+	for k := int64(0); k*size < puzzle.Claim; k++ {
+		ranges <- k
+	}
+	close(ranges)
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prepare writes the stream of prng from start to end of the claim.
+func (s *ConcurrentDiskSolver) prepare(prng PRNG, start, end int64) (err error) {
+	newBlock := func() []byte {
+		return make([]byte, s.BlockSize, s.BlockSize)
+	}
+
+	return generate(prng, start, end, newBlock, false, func(i int64, block []byte) error {
+		_, err := s.w.WriteAt(block, s.Offset+i)
+
+		return err
+	})
+}
+
+// span is a range of the image covering one or more requested indices.
+type span struct {
+	start     int64
+	end       int64
+	positions []position
+}
+
+// spans sorts the indices and coalesces those at most gap bytes apart.
+func spans(indices []int64, gap int64) (result []span) {
+	positions := make([]position, len(indices), len(indices))
+	for i, index := range indices {
+		positions[i] = position{i, index}
+	}
+
+	sort.Slice(positions, func(a, b int) bool {
+		return positions[a].index < positions[b].index
+	})
+
+	for _, p := range positions {
+		if len(result) > 0 {
+			last := &result[len(result)-1]
+
+			if p.index-(last.end-1) <= gap {
+				if p.index >= last.end {
+					last.end = p.index + 1
+				}
+				last.positions = append(last.positions, p)
+
+				continue
+			}
+		}
+
+		result = append(result, span{
+			start:     p.index,
+			end:       p.index + 1,
+			positions: []position{p},
+		})
+	}
+
+	return result
+}
+
+func (s *ConcurrentDiskSolver) fromIndices(indices []int64) (value []byte, err error) {
+	value = make([]byte, len(indices), len(indices))
+
+	var wg sync.WaitGroup
+	var once sync.Once
+
+	inFlight := make(chan struct{}, s.InFlight)
+
+	for _, sp := range spans(indices, s.Gap) {
+		wg.Add(1)
+		inFlight <- struct{}{}
+
+		go func(sp span) {
+			defer wg.Done()
+			defer func() { <-inFlight }()
+
+			buf := make([]byte, sp.end-sp.start, sp.end-sp.start)
+
+			n, rerr := s.r.ReadAt(buf, s.Offset+sp.start)
+			if rerr != nil && !(rerr == io.EOF && n == len(buf)) {
+				once.Do(func() { err = rerr })
+
+				return
+			}
+
+			for _, p := range sp.positions {
+				value[p.i] = buf[p.index-sp.start]
+			}
+		}(sp)
+	}
+
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (s *ConcurrentDiskSolver) Solve(puzzle *Puzzle, preseedIndices []int64, mask []byte) (solution []byte, err error) {
+	return solve(puzzle, preseedIndices, mask, s.fromIndices)
+}
@@ -0,0 +1,97 @@
+package pos_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/calebcase/pos"
+	"github.com/calebcase/pos/lib/aessegprng"
+)
+
+func TestConcurrentDiskSolverPrepare(t *testing.T) {
+	const claim = 100003
+
+	segmented, err := aessegprng.NewSegmentSize(make([]byte, 32, 32), make([]byte, 16, 16), 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	puzzles := map[string]*pos.Puzzle{
+		"aes-ctr": newPuzzle(t, "aes-ctr", claim),
+		"aes-seg": {
+			Claim:         claim,
+			PRNG:          segmented,
+			PreseedRounds: 1,
+			IndexSize:     64,
+			SolutionSize:  8,
+		},
+	}
+
+	for name, puzzle := range puzzles {
+		expectedImage, remove := tempImage(t)
+		defer remove()
+
+		diskSolver, err := pos.NewDiskSolver(expectedImage)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = diskSolver.Prepare(puzzle)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected, err := ioutil.ReadFile(expectedImage.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, workers := range []int{1, 3, 4} {
+			t.Run(fmt.Sprintf("%s/workers=%d", name, workers), func(t *testing.T) {
+				image, remove := tempImage(t)
+				defer remove()
+
+				solver, err := pos.NewConcurrentDiskSolver(image, image, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				solver.BlockSize = 1000
+				solver.Workers = workers
+
+				err = solver.Prepare(puzzle)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				actual, err := ioutil.ReadFile(image.Name())
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !bytes.Equal(actual, expected) {
+					t.Fatal("image differs from a serially prepared image")
+				}
+			})
+		}
+	}
+}
+
+func TestConcurrentDiskSolverPrepareSerial(t *testing.T) {
+	image, remove := tempImage(t)
+	defer remove()
+
+	solver, err := pos.NewConcurrentDiskSolver(image, image, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solver.Workers = 2
+
+	err = solver.Prepare(newPuzzle(t, "aes", 4096))
+	if err != pos.ErrNotSegmented {
+		t.Fatalf("error %v, want %v", err, pos.ErrNotSegmented)
+	}
+}
@@ -0,0 +1,35 @@
+package pos
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// DirectAlignment is the alignment in bytes of the memory, offsets and sizes
+// of writes made by a DiskSolver with Direct set. It satisfies the logical
+// block size of common devices.
+const DirectAlignment = 4096
+
+// ErrDirectUnsupported is returned when direct I/O is not available on the
+// current platform.
+var ErrDirectUnsupported = errors.New("direct I/O is not supported on this platform")
+
+type AlignmentError int64
+
+func (e AlignmentError) Error() string {
+	return fmt.Sprintf("%d is not a multiple of the direct I/O alignment %d", int64(e), DirectAlignment)
+}
+
+// alignedBlock returns a block of the given size whose memory begins on a
+// multiple of DirectAlignment.
+func alignedBlock(size int) []byte {
+	b := make([]byte, size+DirectAlignment, size+DirectAlignment)
+
+	shift := 0
+	if r := int(uintptr(unsafe.Pointer(&b[0])) % DirectAlignment); r != 0 {
+		shift = DirectAlignment - r
+	}
+
+	return b[shift : shift+size : shift+size]
+}
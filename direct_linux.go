@@ -0,0 +1,16 @@
+//go:build linux
+// +build linux
+
+package pos
+
+import (
+	"os"
+	"syscall"
+)
+
+// OpenDirect opens the named file for direct I/O, bypassing the page cache.
+// Writes to the file must be aligned to DirectAlignment. A DiskSolver with
+// Direct set takes care of this.
+func OpenDirect(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return os.OpenFile(name, flag|syscall.O_DIRECT, perm)
+}
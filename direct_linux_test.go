@@ -0,0 +1,71 @@
+package pos_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/calebcase/pos"
+)
+
+func TestDiskSolverDirect(t *testing.T) {
+	// The claim is not a multiple of the alignment so the last block is
+	// padded and the image truncated.
+	const claim = 100003
+
+	puzzle := newPuzzle(t, "aes", claim)
+
+	expectedImage, remove := tempImage(t)
+	defer remove()
+
+	diskSolver, err := pos.NewDiskSolver(expectedImage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = diskSolver.Prepare(puzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := ioutil.ReadFile(expectedImage.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	image, remove := tempImage(t)
+	defer remove()
+
+	direct, err := pos.OpenDirect(image.Name(), os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("direct I/O is unavailable: %v", err)
+	}
+	defer direct.Close()
+
+	diskSolver, err = pos.NewDiskSolver(direct)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diskSolver.Direct = true
+	diskSolver.BlockSize = 4 * pos.DirectAlignment
+
+	err = diskSolver.Prepare(puzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := ioutil.ReadFile(image.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(actual) != claim {
+		t.Fatalf("image size %d, want %d", len(actual), claim)
+	}
+
+	if !bytes.Equal(actual, expected) {
+		t.Fatal("direct image differs from a buffered image")
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package pos
+
+import "os"
+
+// OpenDirect opens the named file for direct I/O. It is only supported on
+// Linux.
+func OpenDirect(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return nil, ErrDirectUnsupported
+}
@@ -1,97 +1,300 @@
 package pos
 
-import "io"
+import (
+	"fmt"
+	"io"
+)
+
+// DefaultBlockSize is the default size in bytes of the blocks written by
+// DiskSolver.Prepare.
+const DefaultBlockSize = 1024 * 1024
+
+type BlockSizeError int
+
+func (e BlockSizeError) Error() string {
+	return fmt.Sprintf("Invalid block size %d", int(e))
+}
 
 type DiskSolver struct {
+	Offset int64 // The offset of the claimed space in the image.
+
+	BlockSize    int  // The size in bytes of the blocks written by Prepare.
+	DoubleBuffer bool // Generate the next block while the previous one is written.
+	Sync         bool // Sync the image before Prepare returns.
+
+	// Direct aligns the writes made by Prepare for an image opened with
+	// OpenDirect. The offset and block size must be multiples of
+	// DirectAlignment. The last block is padded to the alignment and the
+	// image is then truncated to the claim if it has a Truncate method.
+	Direct bool
+
+	// Checkpoint, if set, is called during Prepare with the number of bytes
+	// of the claim written so far. The image is synced first if it has a
+	// Sync method. It is called every CheckpointInterval bytes and once more
+	// when Prepare completes.
+	Checkpoint         func(prepared int64) error
+	CheckpointInterval int64
+
 	out io.ReadWriteSeeker
 }
 
 var _ Solver = (*DiskSolver)(nil)
 
+type syncer interface {
+	Sync() error
+}
+
+type truncater interface {
+	Truncate(size int64) error
+}
+
 func NewDiskSolver(out io.ReadWriteSeeker) (*DiskSolver, error) {
 	return &DiskSolver{
+		BlockSize: DefaultBlockSize,
+
 		out: out,
 	}, nil
 }
 
 func (s *DiskSolver) Prepare(puzzle *Puzzle) (err error) {
+	return s.Resume(puzzle, 0)
+}
+
+// Resume continues a Prepare that was interrupted after the given number of
+// bytes of the claim were written. It should be a value that was passed to
+// Checkpoint. The PRNG is fast forwarded to that offset, seeking directly if
+// it is a SeekablePRNG.
+func (s *DiskSolver) Resume(puzzle *Puzzle, prepared int64) (err error) {
+	if prepared < 0 || prepared > puzzle.Claim {
+		return OffsetError(prepared)
+	}
+
+	if s.BlockSize <= 0 {
+		return BlockSizeError(s.BlockSize)
+	}
+
+	newBlock := func() []byte {
+		return make([]byte, s.BlockSize, s.BlockSize)
+	}
+
+	if s.Direct {
+		if s.BlockSize%DirectAlignment != 0 {
+			return AlignmentError(s.BlockSize)
+		}
+
+		if (s.Offset+prepared)%DirectAlignment != 0 {
+			return AlignmentError(s.Offset + prepared)
+		}
+
+		newBlock = func() []byte {
+			return alignedBlock(s.BlockSize)
+		}
+	}
+
 	prng, err := puzzle.PRNG.Clone()
 	if err != nil {
 		return err
 	}
 
-	const lastSize = 1024
-	last := make([]byte, lastSize, lastSize)
+	_, err = skip(prng, prepared)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.out.Seek(s.Offset+prepared, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	next := prepared + s.CheckpointInterval
+
+	err = generate(prng, prepared, puzzle.Claim, newBlock, s.DoubleBuffer, func(i int64, block []byte) error {
+		data := block
 
-	for i := int64(0); i < puzzle.Claim; i += lastSize {
-		_, err := io.ReadFull(prng, last)
+		if s.Direct && len(data)%DirectAlignment != 0 {
+			// Pad the last block with zeros up to the alignment.
+			size := (len(data) + DirectAlignment - 1) / DirectAlignment * DirectAlignment
+
+			data = data[:size]
+			for j := len(block); j < size; j++ {
+				data[j] = 0
+			}
+		}
+
+		_, err := s.out.Write(data)
 		if err != nil {
 			return err
 		}
 
-		_, err = s.out.Write(last)
+		end := i + int64(len(block))
+
+		if s.CheckpointInterval > 0 && end >= next && end < puzzle.Claim {
+			err = s.checkpoint(end)
+			if err != nil {
+				return err
+			}
+
+			next += s.CheckpointInterval
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if out, ok := s.out.(truncater); ok && s.Direct {
+		err = out.Truncate(s.Offset + puzzle.Claim)
 		if err != nil {
 			return err
 		}
 	}
 
-	return nil
+	if s.Sync && s.Checkpoint == nil {
+		err = s.sync()
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.checkpoint(puzzle.Claim)
 }
 
-func (s *DiskSolver) fromIndices(indices []int64) (value []byte, err error) {
-	value = make([]byte, len(indices), len(indices))
+// generate reads the PRNG stream from start to end in blocks allocated by
+// newBlock and calls fn with the offset of each block. The last block is cut
+// short so the stream stops at end. If doubleBuffer is true the next block is
+// generated while fn handles the previous one.
+func generate(prng PRNG, start, end int64, newBlock func() []byte, doubleBuffer bool, fn func(i int64, block []byte) error) (err error) {
+	if !doubleBuffer {
+		last := newBlock()
+		blockSize := len(last)
 
-	tmp := make([]byte, 1, 1)
+		for i := start; i < end; i += int64(blockSize) {
+			size := int64(blockSize)
+			if end-i < size {
+				size = end - i
+			}
 
-	for i, index := range indices {
-		_, err := s.out.Seek(index, io.SeekStart)
-		if err != nil {
-			return nil, err
-		}
+			_, err := io.ReadFull(prng, last[:size])
+			if err != nil {
+				return err
+			}
 
-		_, err = io.ReadFull(s.out, tmp)
-		if err != nil {
-			return nil, err
+			err = fn(i, last[:size])
+			if err != nil {
+				return err
+			}
 		}
 
-		value[i] = tmp[0]
+		return nil
 	}
 
-	return value, nil
-}
+	first := newBlock()
+	blockSize := len(first)
 
-func (s *DiskSolver) Solve(puzzle *Puzzle, preseedIndices []int64, mask []byte) (solution []byte, err error) {
-	var preseed []byte
+	free := make(chan []byte, 2)
+	free <- first
+	free <- newBlock()
 
-	// First Pass: Read all preseed indices and construct the preseed.
-	for i := int64(0); i < puzzle.PreseedRounds; i++ {
-		preseed, err = s.fromIndices(preseedIndices)
-		if err != nil {
-			return nil, err
+	blocks := make(chan []byte, 2)
+	done := make(chan struct{})
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(blocks)
+
+		for i := start; i < end; i += int64(blockSize) {
+			var last []byte
+
+			select {
+			case last = <-free:
+			case <-done:
+				return
+			}
+
+			size := int64(blockSize)
+			if end-i < size {
+				size = end - i
+			}
+
+			_, err := io.ReadFull(prng, last[:size])
+			if err != nil {
+				errc <- err
+
+				return
+			}
+
+			blocks <- last[:size]
 		}
+	}()
 
-		preseedIndices, err = puzzle.PreseedIndices(int64(len(preseedIndices)), preseed)
+	i := start
+
+	for block := range blocks {
+		err = fn(i, block)
 		if err != nil {
-			return nil, err
+			close(done)
+
+			return err
 		}
 
+		i += int64(len(block))
+		free <- block[:cap(block)]
 	}
 
-	preseed, err = s.fromIndices(preseedIndices)
-	if err != nil {
-		return nil, err
+	select {
+	case err = <-errc:
+		return err
+	default:
+		return nil
 	}
+}
 
-	// Second Pass: Read all the solution indices and construct the solution.
-	solutionIndices, err := puzzle.SolutionIndices(preseed, mask)
-	if err != nil {
-		return nil, err
+// sync syncs the image if it has a Sync method.
+func (s *DiskSolver) sync() error {
+	if out, ok := s.out.(syncer); ok {
+		return out.Sync()
 	}
 
-	solution, err = s.fromIndices(solutionIndices)
+	return nil
+}
+
+// checkpoint syncs the image and reports the progress to Checkpoint.
+func (s *DiskSolver) checkpoint(prepared int64) (err error) {
+	if s.Checkpoint == nil {
+		return nil
+	}
+
+	err = s.sync()
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	return s.Checkpoint(prepared)
+}
+
+func (s *DiskSolver) fromIndices(indices []int64) (value []byte, err error) {
+	value = make([]byte, len(indices), len(indices))
+
+	tmp := make([]byte, 1, 1)
+
+	for i, index := range indices {
+		_, err := s.out.Seek(s.Offset+index, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = io.ReadFull(s.out, tmp)
+		if err != nil {
+			return nil, err
+		}
+
+		value[i] = tmp[0]
 	}
 
-	return solution, nil
+	return value, nil
+}
+
+func (s *DiskSolver) Solve(puzzle *Puzzle, preseedIndices []int64, mask []byte) (solution []byte, err error) {
+	return solve(puzzle, preseedIndices, mask, s.fromIndices)
 }
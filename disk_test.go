@@ -0,0 +1,256 @@
+package pos_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/calebcase/pos"
+)
+
+// tempImage returns an empty file and a function that closes and removes it.
+func tempImage(t testing.TB) (image *os.File, remove func()) {
+	t.Helper()
+
+	image, err := ioutil.TempFile("", "pos-image-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return image, func() {
+		image.Close()
+		os.Remove(image.Name())
+	}
+}
+
+func TestDiskSolver(t *testing.T) {
+	tests := []struct {
+		name         string
+		claim        int64
+		blockSize    int
+		doubleBuffer bool
+	}{
+		{"1 byte", 1, pos.DefaultBlockSize, false},
+		{"17 bytes", 17, 16, false},
+		{"1025 bytes", 1025, 1024, false},
+		{"1025 bytes double buffered", 1025, 1024, true},
+		{"prime 7919", 7919, 1000, false},
+		{"prime 100003", 100003, 4096, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puzzle := newPuzzle(t, "aes", tt.claim)
+			preseedIndices, mask := newChallenge(t, puzzle)
+
+			image, remove := tempImage(t)
+			defer remove()
+
+			diskSolver, err := pos.NewDiskSolver(image)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			diskSolver.BlockSize = tt.blockSize
+			diskSolver.DoubleBuffer = tt.doubleBuffer
+
+			err = diskSolver.Prepare(puzzle)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := image.Stat()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if info.Size() != tt.claim {
+				t.Fatalf("image size %d, want %d", info.Size(), tt.claim)
+			}
+
+			streamSolver, err := pos.NewStreamSolver()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected, err := streamSolver.Solve(puzzle, preseedIndices, mask)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := diskSolver.Solve(puzzle, preseedIndices, mask)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(actual, expected) {
+				t.Fatalf("disk solution %x, want %x", actual, expected)
+			}
+		})
+	}
+}
+
+// TestDiskSolverResume checks that a Prepare stopped by Checkpoint and
+// resumed with a different block size gives the same image as one pass.
+func TestDiskSolverResume(t *testing.T) {
+	const claim = 100003
+
+	errStop := errors.New("stop")
+
+	for _, prngType := range []string{"aes", "aes-ctr"} {
+		t.Run(prngType, func(t *testing.T) {
+			puzzle := newPuzzle(t, prngType, claim)
+
+			expectedImage, remove := tempImage(t)
+			defer remove()
+
+			diskSolver, err := pos.NewDiskSolver(expectedImage)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = diskSolver.Prepare(puzzle)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected, err := ioutil.ReadFile(expectedImage.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			image, remove := tempImage(t)
+			defer remove()
+
+			diskSolver, err = pos.NewDiskSolver(image)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var prepared int64
+
+			diskSolver.BlockSize = 1000
+			diskSolver.CheckpointInterval = 30000
+			diskSolver.Checkpoint = func(p int64) error {
+				prepared = p
+
+				return errStop
+			}
+
+			err = diskSolver.Prepare(puzzle)
+			if err != errStop {
+				t.Fatalf("error %v, want %v", err, errStop)
+			}
+
+			if prepared != 30000 {
+				t.Fatalf("checkpoint at %d, want 30000", prepared)
+			}
+
+			info, err := image.Stat()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if info.Size() != prepared {
+				t.Fatalf("stopped image size %d, want %d", info.Size(), prepared)
+			}
+
+			diskSolver, err = pos.NewDiskSolver(image)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			diskSolver.BlockSize = 777
+
+			err = diskSolver.Resume(puzzle, prepared)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := ioutil.ReadFile(image.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(actual, expected) {
+				t.Fatal("resumed image differs from an image prepared in one pass")
+			}
+		})
+	}
+}
+
+func TestDiskSolverDirectAlignment(t *testing.T) {
+	puzzle := newPuzzle(t, "aes", 100003)
+
+	tests := []struct {
+		name      string
+		offset    int64
+		blockSize int
+		prepared  int64
+		err       error
+	}{
+		{"block size", 0, 1000, 0, pos.AlignmentError(1000)},
+		{"offset", 100, pos.DirectAlignment, 0, pos.AlignmentError(100)},
+		{"resume", pos.DirectAlignment, pos.DirectAlignment, 100, pos.AlignmentError(pos.DirectAlignment + 100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image, remove := tempImage(t)
+			defer remove()
+
+			diskSolver, err := pos.NewDiskSolver(image)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			diskSolver.Direct = true
+			diskSolver.Offset = tt.offset
+			diskSolver.BlockSize = tt.blockSize
+
+			err = diskSolver.Resume(puzzle, tt.prepared)
+			if err != tt.err {
+				t.Fatalf("error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func BenchmarkDiskPrepare(b *testing.B) {
+	puzzle := newPuzzle(b, "aes", 64*1024*1024)
+
+	image, remove := tempImage(b)
+	defer remove()
+
+	for _, blockSize := range []int{1024, 16 * 1024, 256 * 1024, 1024 * 1024, 4 * 1024 * 1024} {
+		for _, doubleBuffer := range []bool{false, true} {
+			name := fmt.Sprintf("%d", blockSize)
+			if doubleBuffer {
+				name += "/double-buffer"
+			}
+
+			b.Run(name, func(b *testing.B) {
+				b.SetBytes(puzzle.Claim)
+
+				for i := 0; i < b.N; i++ {
+					diskSolver, err := pos.NewDiskSolver(image)
+					if err != nil {
+						b.Fatal(err)
+					}
+
+					diskSolver.BlockSize = blockSize
+					diskSolver.DoubleBuffer = doubleBuffer
+					diskSolver.Sync = true
+
+					err = diskSolver.Prepare(puzzle)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
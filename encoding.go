@@ -0,0 +1,153 @@
+package pos
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+)
+
+// The binary encoding of a puzzle is canonical: a puzzle has exactly one
+// encoding, so it can be hashed or signed. It is laid out as follows with
+// integers in big endian order.
+//
+//	0   1  version
+//	1   8  claim
+//	9   8  preseed rounds
+//	17  8  index size
+//	25  8  solution size
+//	33  1  length of the PRNG type (n)
+//	34  n  PRNG type
+//	    2  length of the PRNG seed (m)
+//	    m  PRNG seed
+const (
+	PuzzleVersion = 1
+
+	puzzleFixedSize = 34
+	maxSeedSize     = math.MaxUint16
+)
+
+type PuzzleVersionError uint8
+
+func (e PuzzleVersionError) Error() string {
+	return fmt.Sprintf("Unsupported puzzle version %d", uint8(e))
+}
+
+// PuzzleEncodingError is returned when decoding a puzzle from data that is
+// truncated or has trailing bytes.
+type PuzzleEncodingError int
+
+func (e PuzzleEncodingError) Error() string {
+	return fmt.Sprintf("Invalid puzzle encoding of %d bytes", int(e))
+}
+
+// EncodedSeedSizeError is returned when encoding a puzzle whose seed is too
+// long for the binary encoding.
+type EncodedSeedSizeError int
+
+func (e EncodedSeedSizeError) Error() string {
+	return fmt.Sprintf("Seed of %d bytes is longer than %d bytes", int(e), maxSeedSize)
+}
+
+// PuzzleID identifies a puzzle. It is the SHA-256 of the puzzle's binary
+// encoding.
+type PuzzleID [sha256.Size]byte
+
+func (id PuzzleID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// MarshalBinary returns the canonical binary encoding of the puzzle. The PRNG
+// must be registered with RegisterPRNG.
+func (p *Puzzle) MarshalBinary() (data []byte, err error) {
+	if p.PRNG == nil {
+		return nil, ErrNoPRNG
+	}
+
+	prngType, err := PRNGType(p.PRNG)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(prngType) > maxPRNGTypeSize {
+		return nil, PRNGTypeSizeError(prngType)
+	}
+
+	seed := p.PRNG.GetSeed()
+	if len(seed) > maxSeedSize {
+		return nil, EncodedSeedSizeError(len(seed))
+	}
+
+	size := puzzleFixedSize + len(prngType) + 2 + len(seed)
+	data = make([]byte, size, size)
+
+	data[0] = PuzzleVersion
+	binary.BigEndian.PutUint64(data[1:9], uint64(p.Claim))
+	binary.BigEndian.PutUint64(data[9:17], uint64(p.PreseedRounds))
+	binary.BigEndian.PutUint64(data[17:25], uint64(p.IndexSize))
+	binary.BigEndian.PutUint64(data[25:33], uint64(p.SolutionSize))
+
+	data[33] = byte(len(prngType))
+	i := puzzleFixedSize + copy(data[puzzleFixedSize:], prngType)
+
+	binary.BigEndian.PutUint16(data[i:i+2], uint16(len(seed)))
+	copy(data[i+2:], seed)
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a puzzle encoded by MarshalBinary. The PRNG is
+// created with NewPRNG.
+func (p *Puzzle) UnmarshalBinary(data []byte) (err error) {
+	if len(data) < puzzleFixedSize {
+		return PuzzleEncodingError(len(data))
+	}
+
+	if data[0] != PuzzleVersion {
+		return PuzzleVersionError(data[0])
+	}
+
+	if data[33] > maxPRNGTypeSize {
+		return PuzzleEncodingError(len(data))
+	}
+
+	i := puzzleFixedSize + int(data[33])
+	if len(data) < i+2 {
+		return PuzzleEncodingError(len(data))
+	}
+
+	prngType := string(data[puzzleFixedSize:i])
+
+	end := i + 2 + int(binary.BigEndian.Uint16(data[i:i+2]))
+	if len(data) != end {
+		return PuzzleEncodingError(len(data))
+	}
+
+	seed := make([]byte, end-(i+2), end-(i+2))
+	copy(seed, data[i+2:end])
+
+	prng, err := NewPRNG(prngType, seed)
+	if err != nil {
+		return err
+	}
+
+	p.Claim = int64(binary.BigEndian.Uint64(data[1:9]))
+	p.PreseedRounds = int64(binary.BigEndian.Uint64(data[9:17]))
+	p.IndexSize = int64(binary.BigEndian.Uint64(data[17:25]))
+	p.SolutionSize = int64(binary.BigEndian.Uint64(data[25:33]))
+	p.PRNG = prng
+
+	return nil
+}
+
+// ID returns the SHA-256 of the puzzle's binary encoding. It is stable across
+// JSON encodings of the same puzzle.
+func (p *Puzzle) ID() (id PuzzleID, err error) {
+	data, err := p.MarshalBinary()
+	if err != nil {
+		return id, err
+	}
+
+	return sha256.Sum256(data), nil
+}
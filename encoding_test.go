@@ -0,0 +1,134 @@
+package pos_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/calebcase/pos"
+)
+
+// goldenPuzzle is the encoding of newPuzzle(t, "aes", 4096).
+var goldenPuzzle = strings.Join([]string{
+	"01",               // version
+	"0000000000001000", // claim
+	"0000000000000001", // preseed rounds
+	"0000000000000040", // index size
+	"0000000000000008", // solution size
+	"03",               // length of the PRNG type
+	"616573",           // PRNG type
+	"0030",             // length of the PRNG seed
+	"000102030405060708090a0b0c0d0e0f",
+	"101112131415161718191a1b1c1d1e1f",
+	"202122232425262728292a2b2c2d2e2f",
+}, "")
+
+// goldenPuzzleID is the ID of goldenPuzzle.
+const goldenPuzzleID = "490ce5cf9eb3947bc80272de429dca4d67bf00ac4080bbbc08321fd29b03fcba"
+
+func TestPuzzleEncodingGolden(t *testing.T) {
+	puzzle := newPuzzle(t, "aes", 4096)
+
+	data, err := puzzle.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hex.EncodeToString(data) != goldenPuzzle {
+		t.Fatalf("encoding %x, want %s", data, goldenPuzzle)
+	}
+
+	id, err := puzzle.ID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id.String() != goldenPuzzleID {
+		t.Fatalf("ID %s, want %s", id, goldenPuzzleID)
+	}
+}
+
+func TestPuzzleEncodingRoundTrip(t *testing.T) {
+	for _, prngType := range pos.PRNGTypes() {
+		t.Run(prngType, func(t *testing.T) {
+			puzzle := newPuzzle(t, prngType, 4096)
+
+			data, err := puzzle.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded pos.Puzzle
+
+			err = decoded.UnmarshalBinary(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if decoded.Claim != puzzle.Claim ||
+				decoded.PreseedRounds != puzzle.PreseedRounds ||
+				decoded.IndexSize != puzzle.IndexSize ||
+				decoded.SolutionSize != puzzle.SolutionSize {
+				t.Fatalf("decoded %+v, want %+v", decoded, *puzzle)
+			}
+
+			decodedType, err := pos.PRNGType(decoded.PRNG)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if decodedType != prngType {
+				t.Fatalf("PRNG type %q, want %q", decodedType, prngType)
+			}
+
+			if !bytes.Equal(decoded.PRNG.GetSeed(), puzzle.PRNG.GetSeed()) {
+				t.Fatalf("seed %x, want %x", decoded.PRNG.GetSeed(), puzzle.PRNG.GetSeed())
+			}
+		})
+	}
+}
+
+func TestPuzzleDecodingErrors(t *testing.T) {
+	golden, err := hex.DecodeString(goldenPuzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modify := func(f func(data []byte) []byte) []byte {
+		return f(append([]byte(nil), golden...))
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, pos.PuzzleEncodingError(0)},
+		{"truncated header", golden[:33], pos.PuzzleEncodingError(33)},
+		{"truncated type", golden[:36], pos.PuzzleEncodingError(36)},
+		{"truncated seed", golden[:len(golden)-1], pos.PuzzleEncodingError(len(golden) - 1)},
+		{"trailing bytes", append(golden[:len(golden):len(golden)], 0), pos.PuzzleEncodingError(len(golden) + 1)},
+		{"version", modify(func(data []byte) []byte {
+			data[0] = 2
+
+			return data
+		}), pos.PuzzleVersionError(2)},
+		{"type length", modify(func(data []byte) []byte {
+			data[33] = 33
+
+			return data
+		}), pos.PuzzleEncodingError(len(golden))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var puzzle pos.Puzzle
+
+			err := puzzle.UnmarshalBinary(tt.data)
+			if err != tt.err {
+				t.Fatalf("error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -62,6 +62,9 @@ func main() {
 		panic(err)
 	}
 
+	// Make sure the prepared image is durable before solving.
+	diskSolver.Sync = true
+
 	// Time the stream and disk solver's prepare phase.
 	start = time.Now()
 
@@ -81,8 +84,6 @@ func main() {
 
 	fmt.Printf("Disk Solver Prepared (%s)\n", time.Since(start))
 
-	file.Sync()
-
 	// Select preseed indices and a mask for the solution phase.
 	preseedIdxSeed, err := pos.NewRandomBytes(len(key) + len(iv))
 	if err != nil {
@@ -127,4 +128,19 @@ func main() {
 	fmt.Printf("Disk Solution (%s):\n%x\n", diskSolutionTime, diskSolution)
 
 	fmt.Printf("Solution Ratio: %f\n", float64(streamSolutionTime)/float64(diskSolutionTime))
+
+	// Verify the disk solution without using the space.
+	verifier, err := pos.NewVerifier()
+	if err != nil {
+		panic(err)
+	}
+
+	start = time.Now()
+
+	ok, err := verifier.Verify(puzzle, preseedIndices, mask, diskSolution)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("Verified (%s): %t\n", time.Since(start), ok)
 }
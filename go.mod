@@ -2,4 +2,7 @@ module github.com/calebcase/pos
 
 go 1.12
 
-require github.com/spf13/cobra v0.0.5
+require (
+	github.com/spf13/cobra v0.0.5
+	github.com/spf13/pflag v1.0.5 // indirect
+)
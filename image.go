@@ -0,0 +1,236 @@
+package pos
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// An image is the file a disk solver prepares. It may optionally begin with a
+// header identifying the puzzle it was prepared for, in which case the
+// claimed space begins at ImageHeaderSize.
+//
+// The header is laid out as follows with integers in big endian order. The
+// remainder of the header is zero.
+//
+//	0   8  magic ("POSIMAGE")
+//	8   4  version
+//	16  8  claim
+//	24  1  length of the PRNG type
+//	25  32 PRNG type
+//	64  32 SHA-256 of the PRNG seed
+//	96  8  bytes of the claim prepared
+const (
+	ImageMagic      = "POSIMAGE"
+	ImageVersion    = 1
+	ImageHeaderSize = 4096
+
+	maxPRNGTypeSize = 32
+)
+
+// ErrNoImageHeader is returned when reading the header of an image that does
+// not have one.
+var ErrNoImageHeader = errors.New("image has no header")
+
+// IncompleteImageError is returned when an image has not been fully prepared.
+type IncompleteImageError struct {
+	Prepared int64
+	Claim    int64
+}
+
+func (e IncompleteImageError) Error() string {
+	return fmt.Sprintf("Image is incomplete (%d of %d bytes prepared)", e.Prepared, e.Claim)
+}
+
+type ImageVersionError uint32
+
+func (e ImageVersionError) Error() string {
+	return fmt.Sprintf("Unsupported image version %d", uint32(e))
+}
+
+type PRNGTypeSizeError string
+
+func (e PRNGTypeSizeError) Error() string {
+	return fmt.Sprintf("PRNG type %q is longer than %d bytes", string(e), maxPRNGTypeSize)
+}
+
+// ImageMismatchError is returned when an image was prepared for a different
+// puzzle.
+type ImageMismatchError struct {
+	Field  string
+	Image  string
+	Puzzle string
+}
+
+func (e ImageMismatchError) Error() string {
+	return fmt.Sprintf("Image %s %s does not match puzzle %s %s", e.Field, e.Image, e.Field, e.Puzzle)
+}
+
+// ImageHeader identifies the puzzle an image was prepared for.
+type ImageHeader struct {
+	Version  uint32            // The version of the image format.
+	Claim    int64             // The amount of space in bytes of the puzzle.
+	PRNGType string            // The registered type of the puzzle's PRNG.
+	SeedHash [sha256.Size]byte // The SHA-256 of the puzzle's PRNG seed.
+	Prepared int64             // The number of bytes of the claim durably prepared.
+}
+
+// NewImageHeader returns the header for an image of the given puzzle.
+func NewImageHeader(puzzle *Puzzle) (*ImageHeader, error) {
+	prngType, err := PRNGType(puzzle.PRNG)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(prngType) > maxPRNGTypeSize {
+		return nil, PRNGTypeSizeError(prngType)
+	}
+
+	return &ImageHeader{
+		Version:  ImageVersion,
+		Claim:    puzzle.Claim,
+		PRNGType: prngType,
+		SeedHash: sha256.Sum256(puzzle.PRNG.GetSeed()),
+	}, nil
+}
+
+func (h *ImageHeader) MarshalBinary() ([]byte, error) {
+	if len(h.PRNGType) > maxPRNGTypeSize {
+		return nil, PRNGTypeSizeError(h.PRNGType)
+	}
+
+	b := make([]byte, ImageHeaderSize, ImageHeaderSize)
+
+	copy(b[0:8], ImageMagic)
+	binary.BigEndian.PutUint32(b[8:12], h.Version)
+	binary.BigEndian.PutUint64(b[16:24], uint64(h.Claim))
+	b[24] = byte(len(h.PRNGType))
+	copy(b[25:25+maxPRNGTypeSize], h.PRNGType)
+	copy(b[64:64+sha256.Size], h.SeedHash[:])
+	binary.BigEndian.PutUint64(b[96:104], uint64(h.Prepared))
+
+	return b, nil
+}
+
+func (h *ImageHeader) UnmarshalBinary(b []byte) error {
+	if len(b) < ImageHeaderSize || !bytes.Equal(b[0:8], []byte(ImageMagic)) {
+		return ErrNoImageHeader
+	}
+
+	version := binary.BigEndian.Uint32(b[8:12])
+	if version != ImageVersion {
+		return ImageVersionError(version)
+	}
+
+	size := int(b[24])
+	if size > maxPRNGTypeSize {
+		return PRNGTypeSizeError(b[25 : 25+maxPRNGTypeSize])
+	}
+
+	h.Version = version
+	h.Claim = int64(binary.BigEndian.Uint64(b[16:24]))
+	h.PRNGType = string(b[25 : 25+size])
+	copy(h.SeedHash[:], b[64:64+sha256.Size])
+	h.Prepared = int64(binary.BigEndian.Uint64(b[96:104]))
+
+	return nil
+}
+
+// Check returns an ImageMismatchError if the image was not prepared for the
+// given puzzle. The progress of the preparation is not checked.
+func (h *ImageHeader) Check(puzzle *Puzzle) error {
+	expected, err := NewImageHeader(puzzle)
+	if err != nil {
+		return err
+	}
+
+	if h.Claim != expected.Claim {
+		return ImageMismatchError{
+			Field:  "claim",
+			Image:  fmt.Sprint(h.Claim),
+			Puzzle: fmt.Sprint(expected.Claim),
+		}
+	}
+
+	if h.PRNGType != expected.PRNGType {
+		return ImageMismatchError{
+			Field:  "PRNG type",
+			Image:  h.PRNGType,
+			Puzzle: expected.PRNGType,
+		}
+	}
+
+	if h.SeedHash != expected.SeedHash {
+		return ImageMismatchError{
+			Field:  "seed hash",
+			Image:  fmt.Sprintf("%x", h.SeedHash),
+			Puzzle: fmt.Sprintf("%x", expected.SeedHash),
+		}
+	}
+
+	return nil
+}
+
+// Complete returns an IncompleteImageError if the image has not been fully
+// prepared.
+func (h *ImageHeader) Complete() error {
+	if h.Prepared < h.Claim {
+		return IncompleteImageError{
+			Prepared: h.Prepared,
+			Claim:    h.Claim,
+		}
+	}
+
+	return nil
+}
+
+// Write writes the header to the start of w.
+func (h *ImageHeader) Write(w io.WriterAt) (err error) {
+	b, err := h.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.WriteAt(b, 0)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// WriteImageHeader writes the header for the given puzzle to the start of w.
+func WriteImageHeader(w io.WriterAt, puzzle *Puzzle) (err error) {
+	h, err := NewImageHeader(puzzle)
+	if err != nil {
+		return err
+	}
+
+	return h.Write(w)
+}
+
+// ReadImageHeader reads the header from the start of r. If the image does not
+// have a header ErrNoImageHeader is returned.
+func ReadImageHeader(r io.ReaderAt) (h *ImageHeader, err error) {
+	b := make([]byte, ImageHeaderSize, ImageHeaderSize)
+
+	n, err := r.ReadAt(b, 0)
+	if err == io.EOF {
+		return nil, ErrNoImageHeader
+	}
+	if err != nil && n != len(b) {
+		return nil, err
+	}
+
+	h = &ImageHeader{}
+
+	err = h.UnmarshalBinary(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
@@ -0,0 +1,122 @@
+package pos_test
+
+import (
+	"testing"
+
+	"github.com/calebcase/pos"
+)
+
+func TestImageHeaderCheck(t *testing.T) {
+	puzzle := newPuzzle(t, "aes", 4096)
+
+	image, remove := tempImage(t)
+	defer remove()
+
+	err := pos.WriteImageHeader(image, puzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, err := pos.ReadImageHeader(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = header.Check(puzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherSeed := newPuzzle(t, "aes", 4096)
+	otherSeed.PRNG, err = pos.NewPRNG("aes", make([]byte, 48, 48))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		puzzle *pos.Puzzle
+		field  string
+	}{
+		{"seed", otherSeed, "seed hash"},
+		{"claim", newPuzzle(t, "aes", 8192), "claim"},
+		{"prng", newPuzzle(t, "chacha20", 4096), "PRNG type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := header.Check(tt.puzzle)
+
+			mismatch, ok := err.(pos.ImageMismatchError)
+			if !ok {
+				t.Fatalf("error %v, want ImageMismatchError", err)
+			}
+
+			if mismatch.Field != tt.field {
+				t.Fatalf("mismatched field %q, want %q", mismatch.Field, tt.field)
+			}
+		})
+	}
+}
+
+func TestReadImageHeaderMissing(t *testing.T) {
+	image, remove := tempImage(t)
+	defer remove()
+
+	_, err := pos.ReadImageHeader(image)
+	if err != pos.ErrNoImageHeader {
+		t.Fatalf("error %v, want %v", err, pos.ErrNoImageHeader)
+	}
+
+	// An image prepared without a header begins with the PRNG stream.
+	solver, err := pos.NewDiskSolver(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = solver.Prepare(newPuzzle(t, "aes", 2*pos.ImageHeaderSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = pos.ReadImageHeader(image)
+	if err != pos.ErrNoImageHeader {
+		t.Fatalf("error %v, want %v", err, pos.ErrNoImageHeader)
+	}
+}
+
+func TestImageHeaderComplete(t *testing.T) {
+	puzzle := newPuzzle(t, "aes", 4096)
+
+	header, err := pos.NewImageHeader(puzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header.Prepared = 1000
+
+	image, remove := tempImage(t)
+	defer remove()
+
+	err = header.Write(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, err = pos.ReadImageHeader(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = header.Complete()
+	if err != (pos.IncompleteImageError{Prepared: 1000, Claim: 4096}) {
+		t.Fatalf("error %v, want IncompleteImageError", err)
+	}
+
+	header.Prepared = puzzle.Claim
+
+	err = header.Complete()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,174 @@
+// Package aesctrprng implements a seekable PRNG using AES in counter mode.
+//
+// The byte at any offset of the stream can be computed directly, which makes
+// it useful for testing solvers, building fixtures and generating streams in
+// parallel. See pos.SeekablePRNG for why it must not be used for puzzles that
+// are meant to prove storage.
+package aesctrprng
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/calebcase/pos"
+	"github.com/calebcase/pos/lib/aesprng"
+)
+
+type TypeError string
+
+func (e TypeError) Error() string {
+	return fmt.Sprintf("Invalid type %s", string(e))
+}
+
+// SplitSeed splits a seed into an AES key and initial counter block. The seed
+// sizes are the same as aesprng.
+func SplitSeed(seed []byte) (key, iv []byte, err error) {
+	return aesprng.SplitSeed(seed)
+}
+
+type State struct {
+	key []byte
+	iv  []byte
+
+	block  cipher.Block
+	stream cipher.Stream
+	offset int64
+}
+
+var _ pos.SeekablePRNG = (*State)(nil)
+
+func init() {
+	pos.RegisterPRNG("aes-ctr", 32+16, func(seed []byte) (pos.PRNG, error) {
+		key, iv, err := SplitSeed(seed)
+		if err != nil {
+			return nil, err
+		}
+
+		return New(key, iv)
+	})
+}
+
+func New(key, iv []byte) (prng *State, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(iv) != aes.BlockSize {
+		return nil, aesprng.SeedSizeError(len(key) + len(iv))
+	}
+
+	return &State{
+		key: append([]byte(nil), key...),
+		iv:  append([]byte(nil), iv...),
+
+		block:  block,
+		stream: cipher.NewCTR(block, iv),
+	}, nil
+}
+
+func (prng *State) Read(b []byte) (n int, err error) {
+	for i := range b {
+		b[i] = 0
+	}
+
+	prng.stream.XORKeyStream(b, b)
+	prng.offset += int64(len(b))
+
+	return len(b), nil
+}
+
+// Seek positions the stream at the given offset. The counter block for the
+// offset is derived by adding the block number to the initial counter block.
+func (prng *State) Seek(offset int64, whence int) (int64, error) {
+	offset, err := pos.SeekOffset(prng.offset, offset, whence)
+	if err != nil {
+		return prng.offset, err
+	}
+
+	ctr := make([]byte, aes.BlockSize, aes.BlockSize)
+
+	hi := binary.BigEndian.Uint64(prng.iv[:8])
+	lo := binary.BigEndian.Uint64(prng.iv[8:])
+
+	blocks := uint64(offset / aes.BlockSize)
+	if lo+blocks < lo {
+		hi++
+	}
+	lo += blocks
+
+	binary.BigEndian.PutUint64(ctr[:8], hi)
+	binary.BigEndian.PutUint64(ctr[8:], lo)
+
+	prng.stream = cipher.NewCTR(prng.block, ctr)
+
+	// Discard the start of the block up to the offset.
+	skip := make([]byte, offset%aes.BlockSize, aes.BlockSize)
+	prng.stream.XORKeyStream(skip, skip)
+
+	prng.offset = offset
+
+	return offset, nil
+}
+
+func (prng *State) New(seed []byte) (nprng pos.PRNG, err error) {
+	key, iv, err := SplitSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(key, iv)
+}
+
+func (prng *State) Clone() (nprng pos.PRNG, err error) {
+	return New(prng.key, prng.iv)
+}
+
+func (prng *State) GetSeed() []byte {
+	seed := append([]byte(nil), prng.key...)
+	seed = append(seed, prng.iv...)
+
+	return seed
+}
+
+type serial struct {
+	Type string `json:"type"`
+	Seed []byte `json:"seed"`
+}
+
+func (prng *State) MarshalJSON() ([]byte, error) {
+	return json.Marshal(serial{
+		Type: "aes-ctr",
+		Seed: prng.GetSeed(),
+	})
+}
+
+func (prng *State) UnmarshalJSON(b []byte) (err error) {
+	var s serial
+
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+
+	if s.Type != "aes-ctr" {
+		return TypeError(s.Type)
+	}
+
+	key, iv, err := SplitSeed(s.Seed)
+	if err != nil {
+		return err
+	}
+
+	p, err := New(key, iv)
+	if err != nil {
+		return err
+	}
+
+	*prng = *p
+
+	return nil
+}
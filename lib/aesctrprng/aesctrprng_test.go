@@ -0,0 +1,49 @@
+package aesctrprng_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/calebcase/pos/lib/aesctrprng"
+)
+
+func TestSeek(t *testing.T) {
+	key := make([]byte, 32, 32)
+
+	// The low counter word is close to wrapping so seeking has to carry.
+	iv := bytes.Repeat([]byte{0xff}, 16)
+	iv[0] = 0
+
+	prng, err := aesctrprng.New(key, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream := make([]byte, 1024, 1024)
+
+	_, err = prng.Read(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, offset := range []int64{0, 1, 15, 16, 17, 33, 250, 1000} {
+		_, err = prng.Seek(offset, io.SeekStart)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual := make([]byte, 20, 20)
+
+		_, err = prng.Read(actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := stream[offset : offset+20]
+
+		if !bytes.Equal(actual, expected) {
+			t.Fatalf("offset %d %x, want %x", offset, actual, expected)
+		}
+	}
+}
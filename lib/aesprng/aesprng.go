@@ -49,10 +49,26 @@ type State struct {
 
 	mode cipher.BlockMode
 	zero []byte
+
+	// The unread remainder of the last block generated by a read that was
+	// not a multiple of the block size.
+	block [aes.BlockSize]byte
+	used  int
 }
 
 var _ pos.PRNG = (*State)(nil)
 
+func init() {
+	pos.RegisterPRNG("aes", 32+16, func(seed []byte) (pos.PRNG, error) {
+		key, iv, err := SplitSeed(seed)
+		if err != nil {
+			return nil, err
+		}
+
+		return New(key, iv)
+	})
+}
+
 func New(key, iv []byte) (prng *State, err error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
@@ -65,17 +81,31 @@ func New(key, iv []byte) (prng *State, err error) {
 
 		mode: cipher.NewCBCEncrypter(block, iv),
 		zero: make([]byte, 1024, 1024),
+
+		used: aes.BlockSize,
 	}, nil
 }
 
 func (prng *State) Read(b []byte) (n int, err error) {
-	if len(prng.zero) < len(b) {
-		prng.zero = make([]byte, len(b), len(b))
+	// Use up the remainder of the last block first.
+	c := copy(b, prng.block[prng.used:])
+	prng.used += c
+	b = b[c:]
+
+	whole := len(b) - len(b)%aes.BlockSize
+
+	if len(prng.zero) < whole {
+		prng.zero = make([]byte, whole, whole)
 	}
 
-	prng.mode.CryptBlocks(b, prng.zero[:len(b)])
+	prng.mode.CryptBlocks(b[:whole], prng.zero[:whole])
+
+	if whole < len(b) {
+		prng.mode.CryptBlocks(prng.block[:], prng.zero[:aes.BlockSize])
+		prng.used = copy(b[whole:], prng.block[:])
+	}
 
-	return len(b), nil
+	return c + len(b), nil
 }
 
 func (prng *State) New(seed []byte) (nprng pos.PRNG, err error) {
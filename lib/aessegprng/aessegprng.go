@@ -0,0 +1,220 @@
+// Package aessegprng implements a segmented PRNG using AES in CBC mode.
+//
+// The stream is split into segments of SegmentSize bytes. Segment k is AES in
+// CBC mode over a stream of zeros, as in aesprng, with the IV replaced by the
+// first 16 bytes of SHA-256(iv || k) where k is encoded as a big endian uint64.
+// Within a segment each block depends on the previous one, but segments are
+// independent and can be generated in parallel. See pos.SegmentedPRNG for
+// what that costs in security.
+package aessegprng
+
+import (
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/calebcase/pos"
+	"github.com/calebcase/pos/lib/aesprng"
+)
+
+// SegmentSize is the size in bytes of the segments of the registered PRNG.
+const SegmentSize = 1024 * 1024 * 1024
+
+type TypeError string
+
+func (e TypeError) Error() string {
+	return fmt.Sprintf("Invalid type %s", string(e))
+}
+
+type SegmentSizeError int64
+
+func (e SegmentSizeError) Error() string {
+	return fmt.Sprintf("Invalid segment size %d", int64(e))
+}
+
+type State struct {
+	key         []byte
+	iv          []byte
+	segmentSize int64
+
+	segment int64          // The current segment.
+	used    int64          // The bytes of the current segment read.
+	stream  *aesprng.State // The stream of the current segment.
+}
+
+var _ pos.SegmentedPRNG = (*State)(nil)
+
+func init() {
+	pos.RegisterPRNG("aes-seg", 32+16, func(seed []byte) (pos.PRNG, error) {
+		key, iv, err := aesprng.SplitSeed(seed)
+		if err != nil {
+			return nil, err
+		}
+
+		return New(key, iv)
+	})
+}
+
+func New(key, iv []byte) (prng *State, err error) {
+	return NewSegmentSize(key, iv, SegmentSize)
+}
+
+// NewSegmentSize returns a PRNG with segments of the given size. It is
+// intended for tests. Only a PRNG with the default SegmentSize can be encoded
+// as part of a puzzle.
+func NewSegmentSize(key, iv []byte, segmentSize int64) (prng *State, err error) {
+	if len(iv) != aes.BlockSize {
+		return nil, aesprng.SeedSizeError(len(key) + len(iv))
+	}
+
+	if segmentSize < 1 {
+		return nil, SegmentSizeError(segmentSize)
+	}
+
+	prng = &State{
+		key:         append([]byte(nil), key...),
+		iv:          append([]byte(nil), iv...),
+		segmentSize: segmentSize,
+	}
+
+	err = prng.seek(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return prng, nil
+}
+
+// seek starts the stream of segment k.
+func (prng *State) seek(k int64) (err error) {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(k))
+
+	h := sha256.New()
+	h.Write(prng.iv)
+	h.Write(counter[:])
+
+	prng.stream, err = aesprng.New(prng.key, h.Sum(nil)[:aes.BlockSize])
+	if err != nil {
+		return err
+	}
+
+	prng.segment = k
+	prng.used = 0
+
+	return nil
+}
+
+func (prng *State) Read(b []byte) (n int, err error) {
+	for len(b) > 0 {
+		if prng.used == prng.segmentSize {
+			err = prng.seek(prng.segment + 1)
+			if err != nil {
+				return n, err
+			}
+		}
+
+		size := int64(len(b))
+		if size > prng.segmentSize-prng.used {
+			size = prng.segmentSize - prng.used
+		}
+
+		c, err := prng.stream.Read(b[:size])
+		prng.used += int64(c)
+		n += c
+		if err != nil {
+			return n, err
+		}
+
+		b = b[c:]
+	}
+
+	return n, nil
+}
+
+func (prng *State) SegmentSize() int64 {
+	return prng.segmentSize
+}
+
+func (prng *State) Segment(k int64) (nprng pos.PRNG, err error) {
+	if k < 0 {
+		return nil, pos.OffsetError(k)
+	}
+
+	s, err := NewSegmentSize(prng.key, prng.iv, prng.segmentSize)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.seek(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (prng *State) New(seed []byte) (nprng pos.PRNG, err error) {
+	key, iv, err := aesprng.SplitSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(key, iv)
+}
+
+func (prng *State) Clone() (nprng pos.PRNG, err error) {
+	return NewSegmentSize(prng.key, prng.iv, prng.segmentSize)
+}
+
+func (prng *State) GetSeed() []byte {
+	seed := append([]byte(nil), prng.key...)
+	seed = append(seed, prng.iv...)
+
+	return seed
+}
+
+type serial struct {
+	Type string `json:"type"`
+	Seed []byte `json:"seed"`
+}
+
+func (prng *State) MarshalJSON() ([]byte, error) {
+	if prng.segmentSize != SegmentSize {
+		return nil, SegmentSizeError(prng.segmentSize)
+	}
+
+	return json.Marshal(serial{
+		Type: "aes-seg",
+		Seed: prng.GetSeed(),
+	})
+}
+
+func (prng *State) UnmarshalJSON(b []byte) (err error) {
+	var s serial
+
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+
+	if s.Type != "aes-seg" {
+		return TypeError(s.Type)
+	}
+
+	key, iv, err := aesprng.SplitSeed(s.Seed)
+	if err != nil {
+		return err
+	}
+
+	p, err := New(key, iv)
+	if err != nil {
+		return err
+	}
+
+	*prng = *p
+
+	return nil
+}
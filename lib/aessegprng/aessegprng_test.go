@@ -0,0 +1,86 @@
+package aessegprng_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/calebcase/pos/lib/aessegprng"
+)
+
+func TestSegment(t *testing.T) {
+	key := make([]byte, 32, 32)
+	iv := make([]byte, 16, 16)
+
+	prng, err := aessegprng.NewSegmentSize(key, iv, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream := make([]byte, 1000, 1000)
+
+	_, err = prng.Read(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for k := int64(0); k < 9; k++ {
+		segment, err := prng.Segment(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual := make([]byte, 150, 150)
+
+		_, err = segment.Read(actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := stream[k*100 : k*100+150]
+
+		if !bytes.Equal(actual, expected) {
+			t.Fatalf("segment %d %x, want %x", k, actual, expected)
+		}
+	}
+
+	if bytes.Equal(stream[:100], stream[100:200]) {
+		t.Fatal("segments are identical")
+	}
+}
+
+func TestMarshalSegmentSize(t *testing.T) {
+	key := make([]byte, 32, 32)
+	iv := make([]byte, 16, 16)
+
+	prng, err := aessegprng.NewSegmentSize(key, iv, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = json.Marshal(prng)
+	if err == nil {
+		t.Fatal("marshaled a PRNG with a non-default segment size")
+	}
+
+	prng, err = aessegprng.New(key, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(prng)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded aessegprng.State
+
+	err = json.Unmarshal(b, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.SegmentSize() != aessegprng.SegmentSize || !bytes.Equal(decoded.GetSeed(), prng.GetSeed()) {
+		t.Fatalf("decoded %s differently", b)
+	}
+}
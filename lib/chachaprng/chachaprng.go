@@ -0,0 +1,238 @@
+// Package chachaprng implements a PRNG using the ChaCha20 stream cipher.
+//
+// The first block is the original ChaCha20 keystream block for the key and 64
+// bit nonce. Each following block is chained to the one before it: the previous
+// block's first 40 bytes are XORed into the key and nonce used to generate it.
+// As with aesprng in CBC mode the stream can only be generated serially, so a
+// prover cannot compute the bytes at an offset without generating everything
+// before it. It does not depend on hardware acceleration, so its throughput is
+// more consistent across hosts than aesprng.
+package chachaprng
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/bits"
+
+	"github.com/calebcase/pos"
+)
+
+const (
+	KeySize   = 32
+	NonceSize = 8
+	SeedSize  = KeySize + NonceSize
+
+	blockSize = 64
+)
+
+type SeedSizeError int
+
+func (e SeedSizeError) Error() string {
+	return fmt.Sprintf("Invalid seed size %d", int(e))
+}
+
+type TypeError string
+
+func (e TypeError) Error() string {
+	return fmt.Sprintf("Invalid type %s", string(e))
+}
+
+func SplitSeed(seed []byte) (key, nonce []byte, err error) {
+	if len(seed) != SeedSize {
+		return nil, nil, SeedSizeError(len(seed))
+	}
+
+	key = seed[:KeySize]
+	nonce = seed[KeySize:]
+
+	return key, nonce, nil
+}
+
+type State struct {
+	key   []byte
+	nonce []byte
+
+	input   [16]uint32
+	counter uint64
+	block   [blockSize]byte
+	used    int
+}
+
+var _ pos.PRNG = (*State)(nil)
+
+func init() {
+	pos.RegisterPRNG("chacha20", SeedSize, func(seed []byte) (pos.PRNG, error) {
+		key, nonce, err := SplitSeed(seed)
+		if err != nil {
+			return nil, err
+		}
+
+		return New(key, nonce)
+	})
+}
+
+func New(key, nonce []byte) (prng *State, err error) {
+	if len(key) != KeySize || len(nonce) != NonceSize {
+		return nil, SeedSizeError(len(key) + len(nonce))
+	}
+
+	prng = &State{
+		key:   append([]byte(nil), key...),
+		nonce: append([]byte(nil), nonce...),
+
+		used: blockSize,
+	}
+
+	// "expand 32-byte k"
+	prng.input[0] = 0x61707865
+	prng.input[1] = 0x3320646e
+	prng.input[2] = 0x79622d32
+	prng.input[3] = 0x6b206574
+
+	for i := 0; i < 8; i++ {
+		prng.input[4+i] = binary.LittleEndian.Uint32(key[i*4:])
+	}
+
+	prng.input[14] = binary.LittleEndian.Uint32(nonce[0:])
+	prng.input[15] = binary.LittleEndian.Uint32(nonce[4:])
+
+	return prng, nil
+}
+
+func quarterRound(a, b, c, d uint32) (uint32, uint32, uint32, uint32) {
+	a += b
+	d = bits.RotateLeft32(d^a, 16)
+	c += d
+	b = bits.RotateLeft32(b^c, 12)
+	a += b
+	d = bits.RotateLeft32(d^a, 8)
+	c += d
+	b = bits.RotateLeft32(b^c, 7)
+
+	return a, b, c, d
+}
+
+// generate writes the next block to out and chains it into the key and nonce
+// used for the block after it.
+func (prng *State) generate(out []byte) {
+	in := prng.input
+	in[12] = uint32(prng.counter)
+	in[13] = uint32(prng.counter >> 32)
+
+	x := in
+
+	for i := 0; i < 10; i++ {
+		x[0], x[4], x[8], x[12] = quarterRound(x[0], x[4], x[8], x[12])
+		x[1], x[5], x[9], x[13] = quarterRound(x[1], x[5], x[9], x[13])
+		x[2], x[6], x[10], x[14] = quarterRound(x[2], x[6], x[10], x[14])
+		x[3], x[7], x[11], x[15] = quarterRound(x[3], x[7], x[11], x[15])
+
+		x[0], x[5], x[10], x[15] = quarterRound(x[0], x[5], x[10], x[15])
+		x[1], x[6], x[11], x[12] = quarterRound(x[1], x[6], x[11], x[12])
+		x[2], x[7], x[8], x[13] = quarterRound(x[2], x[7], x[8], x[13])
+		x[3], x[4], x[9], x[14] = quarterRound(x[3], x[4], x[9], x[14])
+	}
+
+	for i := range x {
+		x[i] += in[i]
+		binary.LittleEndian.PutUint32(out[i*4:], x[i])
+	}
+
+	// Words 4 through 11 hold the key and 14 and 15 the nonce.
+	for i := 0; i < 8; i++ {
+		prng.input[4+i] ^= x[i]
+	}
+
+	prng.input[14] ^= x[8]
+	prng.input[15] ^= x[9]
+
+	prng.counter++
+}
+
+func (prng *State) Read(b []byte) (n int, err error) {
+	for len(b) > 0 {
+		if prng.used == blockSize {
+			// Generate whole blocks directly into the output when
+			// possible.
+			if len(b) >= blockSize {
+				prng.generate(b)
+
+				b = b[blockSize:]
+				n += blockSize
+
+				continue
+			}
+
+			prng.generate(prng.block[:])
+			prng.used = 0
+		}
+
+		c := copy(b, prng.block[prng.used:])
+		prng.used += c
+
+		b = b[c:]
+		n += c
+	}
+
+	return n, nil
+}
+
+func (prng *State) New(seed []byte) (nprng pos.PRNG, err error) {
+	key, nonce, err := SplitSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(key, nonce)
+}
+
+func (prng *State) Clone() (nprng pos.PRNG, err error) {
+	return New(prng.key, prng.nonce)
+}
+
+func (prng *State) GetSeed() []byte {
+	seed := append([]byte(nil), prng.key...)
+	seed = append(seed, prng.nonce...)
+
+	return seed
+}
+
+type serial struct {
+	Type string `json:"type"`
+	Seed []byte `json:"seed"`
+}
+
+func (prng *State) MarshalJSON() ([]byte, error) {
+	return json.Marshal(serial{
+		Type: "chacha20",
+		Seed: prng.GetSeed(),
+	})
+}
+
+func (prng *State) UnmarshalJSON(b []byte) (err error) {
+	var s serial
+
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+
+	if s.Type != "chacha20" {
+		return TypeError(s.Type)
+	}
+
+	key, nonce, err := SplitSeed(s.Seed)
+	if err != nil {
+		return err
+	}
+
+	p, err := New(key, nonce)
+	if err != nil {
+		return err
+	}
+
+	*prng = *p
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package chachaprng_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/calebcase/pos/lib/chachaprng"
+)
+
+// The first block is the ChaCha20 keystream block for the all zero key and
+// nonce. The second block was computed with golang.org/x/crypto/chacha20 using
+// the key and nonce chained from the first.
+const known = "" +
+	"76b8e0ada0f13d90405d6ae55386bd28bdd219b8a08ded1aa836efcc8b770dc7" +
+	"da41597c5157488d7724e03fb8d84a376a43b8f41518a11cc387b669b2ee6586" +
+	"89f67f3bb6bc97b1eeb15df0c8f3b6b5ea6048889cb9665ea8c34cf518f0b4e2" +
+	"6652ebbcea7e04f6ecdcf3d74a008d8bb9481194db4ff477560584dcec24b8c4"
+
+func TestKnownAnswer(t *testing.T) {
+	expected, err := hex.DecodeString(known)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prng, err := chachaprng.New(make([]byte, chachaprng.KeySize), make([]byte, chachaprng.NonceSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := make([]byte, len(expected), len(expected))
+
+	_, err = prng.Read(actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(actual, expected) {
+		t.Fatalf("stream %x, want %x", actual, expected)
+	}
+}
+
+func TestReadSizes(t *testing.T) {
+	seed := make([]byte, chachaprng.SeedSize, chachaprng.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+
+	key, nonce, err := chachaprng.SplitSeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prng, err := chachaprng.New(key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := make([]byte, 1000, 1000)
+
+	_, err = prng.Read(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clone, err := prng.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := make([]byte, 0, len(expected))
+
+	for _, size := range []int{1, 63, 64, 65, 128, 7, 300, 372} {
+		b := make([]byte, size, size)
+
+		_, err = clone.Read(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual = append(actual, b...)
+	}
+
+	if !bytes.Equal(actual, expected) {
+		t.Fatal("stream depends on the sizes of the reads")
+	}
+}
@@ -0,0 +1,337 @@
+// Package httpapi exposes the challenger side of the proof of space exchange
+// as a JSON over HTTP API.
+//
+// The endpoints follow the sequence in diagram/exchange.uml:
+//
+//	POST /claims                      {"claim": <bytes>} -> {"id": ..., "puzzle": ...}
+//	POST /claims/{id}/prepared        -> 204 No Content
+//	POST /claims/{id}/challenges      -> {"preseed_indices": ..., "mask": ..., ...}
+//	POST /claims/{id}/responses       {"solution": ...} -> verdict
+//
+// A claim has at most one outstanding challenge. Another challenge is refused
+// until the outstanding one has been responded to, so a prover cannot discard
+// a challenge it is unable to answer in time and ask for a new one.
+//
+// Errors are reported with an appropriate status code and a body of the form
+// {"error": <message>}.
+package httpapi
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/calebcase/pos"
+)
+
+// MaxBodySize is the largest request body that will be read.
+const MaxBodySize = 1024 * 1024
+
+const (
+	// DefaultClaimTTL is how long an idle claim is kept.
+	DefaultClaimTTL = 24 * time.Hour
+
+	// DefaultMaxClaims is the number of claims kept at once.
+	DefaultMaxClaims = 10000
+)
+
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e StatusError) Error() string {
+	return e.Message
+}
+
+// ClaimRequest is the body of a request to create a claim.
+type ClaimRequest struct {
+	Claim int64 `json:"claim"` // The amount of space in bytes being claimed.
+}
+
+// ClaimResponse is the body of the response to creating a claim.
+type ClaimResponse struct {
+	ID     string      `json:"id"`     // The identifier of the claim.
+	Puzzle *pos.Puzzle `json:"puzzle"` // The puzzle the prover must prepare.
+}
+
+// SolutionRequest is the body of a response to a challenge.
+type SolutionRequest struct {
+	Solution []byte `json:"solution"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type claim struct {
+	mu sync.Mutex
+
+	puzzle   *pos.Puzzle
+	prepared bool
+	session  *pos.Session
+
+	touched time.Time // The last time the claim was used. Guarded by Handler.mu.
+}
+
+// Handler serves the challenger API. Claims are kept in memory.
+type Handler struct {
+	// NewPuzzle generates a puzzle for the claimed amount of space.
+	NewPuzzle func(claim int64) (*pos.Puzzle, error)
+
+	Allowed time.Duration // The allowed time for a solution.
+	Clock   pos.Clock     // The clock used to time the solution.
+
+	ClaimTTL  time.Duration // Idle claims are forgotten after this long.
+	MaxClaims int           // New claims are refused while this many are kept.
+
+	mu     sync.Mutex
+	claims map[string]*claim
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+func NewHandler(newPuzzle func(claim int64) (*pos.Puzzle, error), allowed time.Duration) (*Handler, error) {
+	return &Handler{
+		NewPuzzle: newPuzzle,
+		Allowed:   allowed,
+		Clock:     pos.SystemClock,
+
+		ClaimTTL:  DefaultClaimTTL,
+		MaxClaims: DefaultMaxClaims,
+
+		claims: make(map[string]*claim),
+	}, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, StatusError{http.StatusMethodNotAllowed, "method not allowed"})
+
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "claims" {
+		writeError(w, StatusError{http.StatusNotFound, "not found"})
+
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodySize)
+
+	var status int
+	var v interface{}
+	var err error
+
+	switch {
+	case len(parts) == 1:
+		status = http.StatusCreated
+		v, err = h.createClaim(r)
+	case len(parts) == 3 && parts[2] == "prepared":
+		status = http.StatusNoContent
+		err = h.prepared(parts[1])
+	case len(parts) == 3 && parts[2] == "challenges":
+		status = http.StatusCreated
+		v, err = h.challenge(parts[1])
+	case len(parts) == 3 && parts[2] == "responses":
+		status = http.StatusOK
+		v, err = h.respond(parts[1], r)
+	default:
+		err = StatusError{http.StatusNotFound, "not found"}
+	}
+
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	if v == nil {
+		w.WriteHeader(status)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	// The status has already been sent so there is nothing useful to do
+	// with an encoding error.
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	if se, ok := err.(StatusError); ok {
+		code = se.Code
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	json.NewEncoder(w).Encode(errorResponse{
+		Error: err.Error(),
+	})
+}
+
+// evict forgets claims that have been idle for longer than the TTL. The
+// caller must hold h.mu.
+func (h *Handler) evict(now time.Time) {
+	for id, c := range h.claims {
+		if now.Sub(c.touched) > h.ClaimTTL {
+			delete(h.claims, id)
+		}
+	}
+}
+
+// lookup finds the claim and marks it as used. The claim is returned locked.
+func (h *Handler) lookup(id string) (*claim, error) {
+	h.mu.Lock()
+
+	now := h.Clock.Now()
+
+	c, ok := h.claims[id]
+	if ok && now.Sub(c.touched) > h.ClaimTTL {
+		delete(h.claims, id)
+		ok = false
+	}
+
+	if !ok {
+		h.mu.Unlock()
+
+		return nil, StatusError{http.StatusNotFound, fmt.Sprintf("unknown claim %q", id)}
+	}
+
+	c.touched = now
+
+	h.mu.Unlock()
+
+	c.mu.Lock()
+
+	return c, nil
+}
+
+func (h *Handler) createClaim(r *http.Request) (resp *ClaimResponse, err error) {
+	var req ClaimRequest
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return nil, StatusError{http.StatusBadRequest, err.Error()}
+	}
+
+	if req.Claim <= 0 {
+		return nil, StatusError{http.StatusBadRequest, fmt.Sprintf("invalid claim %d", req.Claim)}
+	}
+
+	puzzle, err := h.NewPuzzle(req.Claim)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pos.CheckNotSeekable(puzzle.PRNG)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := pos.NewRandomBytes(16)
+	if err != nil {
+		return nil, err
+	}
+
+	id := hex.EncodeToString(b)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.Clock.Now()
+
+	if len(h.claims) >= h.MaxClaims {
+		h.evict(now)
+	}
+
+	if len(h.claims) >= h.MaxClaims {
+		return nil, StatusError{http.StatusServiceUnavailable, "too many claims"}
+	}
+
+	h.claims[id] = &claim{
+		puzzle:  puzzle,
+		touched: now,
+	}
+
+	return &ClaimResponse{
+		ID:     id,
+		Puzzle: puzzle,
+	}, nil
+}
+
+func (h *Handler) prepared(id string) (err error) {
+	c, err := h.lookup(id)
+	if err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+
+	c.prepared = true
+
+	return nil
+}
+
+func (h *Handler) challenge(id string) (challenge *pos.Challenge, err error) {
+	c, err := h.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	if !c.prepared {
+		return nil, StatusError{http.StatusConflict, "claim is not prepared"}
+	}
+
+	if c.session == nil {
+		c.session, err = pos.NewSession(c.puzzle, h.Allowed, h.Clock)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.session.Challenge() != nil {
+		return nil, StatusError{http.StatusConflict, "challenge is outstanding"}
+	}
+
+	return c.session.Issue()
+}
+
+func (h *Handler) respond(id string, r *http.Request) (verdict *pos.Verdict, err error) {
+	var req SolutionRequest
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return nil, StatusError{http.StatusBadRequest, err.Error()}
+	}
+
+	c, err := h.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	if c.session == nil {
+		return nil, StatusError{http.StatusConflict, pos.ErrNoChallenge.Error()}
+	}
+
+	verdict, err = c.session.Respond(req.Solution)
+	if err == pos.ErrNoChallenge {
+		return nil, StatusError{http.StatusConflict, err.Error()}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return verdict, nil
+}
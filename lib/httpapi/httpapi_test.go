@@ -0,0 +1,280 @@
+package httpapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/calebcase/pos"
+	_ "github.com/calebcase/pos/lib/aesprng"
+	"github.com/calebcase/pos/lib/httpapi"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newPuzzle(claim int64) (*pos.Puzzle, error) {
+	seed, err := pos.NewRandomBytes(32 + 16)
+	if err != nil {
+		return nil, err
+	}
+
+	prng, err := pos.NewPRNG("aes", seed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pos.Puzzle{
+		Claim:         claim,
+		PRNG:          prng,
+		PreseedRounds: 1,
+		IndexSize:     64,
+		SolutionSize:  8,
+	}, nil
+}
+
+func newServer(t *testing.T) (*httptest.Server, *httpapi.Handler, *fakeClock) {
+	t.Helper()
+
+	h, err := httpapi.NewHandler(newPuzzle, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := &fakeClock{now: time.Unix(1000000000, 0)}
+	h.Clock = clock
+
+	return httptest.NewServer(h), h, clock
+}
+
+// post sends v as JSON and decodes the response into out. The status code is
+// returned.
+func post(t *testing.T, url string, v, out interface{}) int {
+	t.Helper()
+
+	var body bytes.Buffer
+
+	if v != nil {
+		err := json.NewEncoder(&body).Encode(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := http.Post(url, "application/json", &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode < 300 {
+		err = json.NewDecoder(resp.Body).Decode(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return resp.StatusCode
+}
+
+func createClaim(t *testing.T, url string, claim int64) *httpapi.ClaimResponse {
+	t.Helper()
+
+	var cr httpapi.ClaimResponse
+
+	code := post(t, url+"/claims", httpapi.ClaimRequest{Claim: claim}, &cr)
+	if code != http.StatusCreated {
+		t.Fatalf("create claim status %d, want %d", code, http.StatusCreated)
+	}
+
+	return &cr
+}
+
+func TestExchange(t *testing.T) {
+	srv, _, clock := newServer(t)
+	defer srv.Close()
+
+	cr := createClaim(t, srv.URL, 64*1024)
+	claimURL := srv.URL + "/claims/" + cr.ID
+
+	code := post(t, claimURL+"/challenges", nil, nil)
+	if code != http.StatusConflict {
+		t.Fatalf("challenge before prepared status %d, want %d", code, http.StatusConflict)
+	}
+
+	solver, err := pos.NewMemorySolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = solver.Prepare(cr.Puzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code = post(t, claimURL+"/prepared", nil, nil)
+	if code != http.StatusNoContent {
+		t.Fatalf("prepared status %d, want %d", code, http.StatusNoContent)
+	}
+
+	code = post(t, claimURL+"/responses", httpapi.SolutionRequest{}, nil)
+	if code != http.StatusConflict {
+		t.Fatalf("response without challenge status %d, want %d", code, http.StatusConflict)
+	}
+
+	for round := 0; round < 2; round++ {
+		var challenge pos.Challenge
+
+		code = post(t, claimURL+"/challenges", nil, &challenge)
+		if code != http.StatusCreated {
+			t.Fatalf("challenge status %d, want %d", code, http.StatusCreated)
+		}
+
+		code = post(t, claimURL+"/challenges", nil, nil)
+		if code != http.StatusConflict {
+			t.Fatalf("second challenge status %d, want %d", code, http.StatusConflict)
+		}
+
+		solution, err := solver.Solve(cr.Puzzle, challenge.PreseedIndices, challenge.Mask)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		clock.now = clock.now.Add(time.Second)
+
+		var verdict pos.Verdict
+
+		code = post(t, claimURL+"/responses", httpapi.SolutionRequest{Solution: solution}, &verdict)
+		if code != http.StatusOK {
+			t.Fatalf("response status %d, want %d", code, http.StatusOK)
+		}
+
+		if !verdict.Accepted || verdict.Duration != time.Second {
+			t.Fatalf("verdict %+v, want accepted after 1s", verdict)
+		}
+	}
+}
+
+func TestLateResponse(t *testing.T) {
+	srv, _, clock := newServer(t)
+	defer srv.Close()
+
+	cr := createClaim(t, srv.URL, 64*1024)
+	claimURL := srv.URL + "/claims/" + cr.ID
+
+	post(t, claimURL+"/prepared", nil, nil)
+
+	var challenge pos.Challenge
+
+	post(t, claimURL+"/challenges", nil, &challenge)
+
+	solver, err := pos.NewMemorySolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = solver.Prepare(cr.Puzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solution, err := solver.Solve(cr.Puzzle, challenge.PreseedIndices, challenge.Mask)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.now = clock.now.Add(11 * time.Second)
+
+	var verdict pos.Verdict
+
+	code := post(t, claimURL+"/responses", httpapi.SolutionRequest{Solution: solution}, &verdict)
+	if code != http.StatusOK {
+		t.Fatalf("response status %d, want %d", code, http.StatusOK)
+	}
+
+	if verdict.Accepted || !verdict.Late {
+		t.Fatalf("verdict %+v, want late rejection", verdict)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	srv, _, _ := newServer(t)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/claims")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("GET status %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		body interface{}
+		code int
+	}{
+		{"unknown path", "/puzzles", nil, http.StatusNotFound},
+		{"unknown claim", "/claims/0123/prepared", nil, http.StatusNotFound},
+		{"unknown action", "/claims/0123/other", nil, http.StatusNotFound},
+		{"empty body", "/claims", nil, http.StatusBadRequest},
+		{"invalid claim", "/claims", httpapi.ClaimRequest{Claim: -1}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := post(t, srv.URL+tt.path, tt.body, nil)
+			if code != tt.code {
+				t.Fatalf("status %d, want %d", code, tt.code)
+			}
+		})
+	}
+}
+
+func TestEviction(t *testing.T) {
+	srv, h, clock := newServer(t)
+	defer srv.Close()
+
+	h.ClaimTTL = time.Hour
+	h.MaxClaims = 1
+
+	cr := createClaim(t, srv.URL, 64*1024)
+
+	code := post(t, srv.URL+"/claims", httpapi.ClaimRequest{Claim: 64 * 1024}, nil)
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("create claim status %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	// Using the claim keeps it alive.
+	clock.now = clock.now.Add(time.Hour)
+
+	code = post(t, srv.URL+"/claims/"+cr.ID+"/prepared", nil, nil)
+	if code != http.StatusNoContent {
+		t.Fatalf("prepared status %d, want %d", code, http.StatusNoContent)
+	}
+
+	clock.now = clock.now.Add(time.Hour + time.Nanosecond)
+
+	code = post(t, srv.URL+"/claims/"+cr.ID+"/challenges", nil, nil)
+	if code != http.StatusNotFound {
+		t.Fatalf("expired claim status %d, want %d", code, http.StatusNotFound)
+	}
+
+	// An idle claim is evicted to make room for a new one.
+	createClaim(t, srv.URL, 64*1024)
+
+	clock.now = clock.now.Add(time.Hour + time.Nanosecond)
+
+	createClaim(t, srv.URL, 64*1024)
+}
@@ -0,0 +1,106 @@
+package protocol
+
+import (
+	"io"
+	"time"
+
+	"github.com/calebcase/pos"
+)
+
+// Challenger runs the challenger side of the exchange for a single claim.
+type Challenger struct {
+	// NewPuzzle generates a puzzle for the claimed amount of space.
+	NewPuzzle func(claim int64) (*pos.Puzzle, error)
+
+	Allowed time.Duration // The allowed time for a solution.
+	Clock   pos.Clock     // The clock used to time the solution.
+}
+
+func NewChallenger(newPuzzle func(claim int64) (*pos.Puzzle, error), allowed time.Duration) (*Challenger, error) {
+	return &Challenger{
+		NewPuzzle: newPuzzle,
+		Allowed:   allowed,
+		Clock:     pos.SystemClock,
+	}, nil
+}
+
+// Run performs the exchange with the prover on rw and returns the verdict
+// that was sent to the prover.
+func (ch *Challenger) Run(rw io.ReadWriter) (verdict *pos.Verdict, err error) {
+	c := NewConn(rw)
+
+	err = c.hello(true)
+	if err != nil {
+		return nil, err
+	}
+
+	var claim Claim
+
+	err = c.Receive(TypeClaim, &claim)
+	if err != nil {
+		return nil, err
+	}
+
+	puzzle, err := ch.NewPuzzle(claim.Claim)
+	if err != nil {
+		return nil, c.Fail(err)
+	}
+
+	if puzzle.Claim != claim.Claim {
+		return nil, c.Fail(ClaimMismatchError{claim.Claim, puzzle.Claim})
+	}
+
+	err = pos.CheckNotSeekable(puzzle.PRNG)
+	if err != nil {
+		return nil, c.Fail(err)
+	}
+
+	err = c.Send(TypePuzzle, puzzle)
+	if err != nil {
+		return nil, err
+	}
+
+	var prepared Prepared
+
+	err = c.Receive(TypePrepared, &prepared)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := pos.NewSession(puzzle, ch.Allowed, ch.Clock)
+	if err != nil {
+		return nil, c.Fail(err)
+	}
+
+	challenge, err := session.Issue()
+	if err != nil {
+		return nil, c.Fail(err)
+	}
+
+	err = c.Send(TypeChallenge, Challenge{
+		PreseedIndices: challenge.PreseedIndices,
+		Mask:           challenge.Mask,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var solution Solution
+
+	err = c.Receive(TypeSolution, &solution)
+	if err != nil {
+		return nil, err
+	}
+
+	verdict, err = session.Respond(solution.Solution)
+	if err != nil {
+		return nil, c.Fail(err)
+	}
+
+	err = c.Send(TypeVerdict, Verdict{Verdict: *verdict})
+	if err != nil {
+		return nil, err
+	}
+
+	return verdict, nil
+}
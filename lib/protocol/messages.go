@@ -0,0 +1,37 @@
+package protocol
+
+import "github.com/calebcase/pos"
+
+// Hello is exchanged first to agree on the protocol version.
+type Hello struct {
+	Version int `json:"version"`
+}
+
+// Claim is sent by the prover to claim an amount of space.
+type Claim struct {
+	Claim int64 `json:"claim"` // The amount of space in bytes being claimed.
+}
+
+// Prepared is sent by the prover when it is ready to solve.
+type Prepared struct{}
+
+// Challenge is sent by the challenger to start the solve phase.
+type Challenge struct {
+	PreseedIndices []int64 `json:"preseed_indices"`
+	Mask           []byte  `json:"mask"`
+}
+
+// Solution is sent by the prover in response to a challenge.
+type Solution struct {
+	Solution []byte `json:"solution"`
+}
+
+// Verdict is sent by the challenger after checking the solution.
+type Verdict struct {
+	pos.Verdict
+}
+
+// Error may be sent by either side in place of the expected message.
+type Error struct {
+	Message string `json:"message"`
+}
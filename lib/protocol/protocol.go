@@ -0,0 +1,244 @@
+// Package protocol implements a versioned wire protocol for running the proof
+// of space exchange between a prover and a challenger over a network.
+//
+// Every message is sent as a frame consisting of a 4 byte big endian length,
+// a 1 byte message type and a JSON encoded payload. The length covers the
+// type and the payload. The exchange follows the sequence in
+// diagram/exchange.uml:
+//
+//	Challenger -> Prover : hello
+//	Prover -> Challenger : hello
+//	Prover -> Challenger : claim
+//	Challenger -> Prover : puzzle
+//	Prover -> Challenger : prepared
+//	Challenger -> Prover : challenge
+//	Prover -> Challenger : solution
+//	Challenger -> Prover : verdict
+//
+// The puzzle message carries a pos.Puzzle in its JSON encoding, so the
+// package implementing its PRNG must be imported by the prover.
+//
+// Either side may send an error message in place of the expected message
+// before closing the connection.
+package protocol
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Version is the protocol version spoken by this package.
+const Version = 1
+
+// MaxFrameSize is the largest frame that will be read.
+const MaxFrameSize = 16 * 1024 * 1024
+
+// Type identifies the message carried in a frame.
+type Type uint8
+
+const (
+	TypeError Type = iota
+	TypeHello
+	TypeClaim
+	TypePuzzle
+	TypePrepared
+	TypeChallenge
+	TypeSolution
+	TypeVerdict
+)
+
+var typeNames = map[Type]string{
+	TypeError:     "error",
+	TypeHello:     "hello",
+	TypeClaim:     "claim",
+	TypePuzzle:    "puzzle",
+	TypePrepared:  "prepared",
+	TypeChallenge: "challenge",
+	TypeSolution:  "solution",
+	TypeVerdict:   "verdict",
+}
+
+func (t Type) String() string {
+	name, ok := typeNames[t]
+	if !ok {
+		return fmt.Sprintf("unknown(%d)", uint8(t))
+	}
+
+	return name
+}
+
+type VersionError int
+
+func (e VersionError) Error() string {
+	return fmt.Sprintf("Unsupported protocol version %d", int(e))
+}
+
+type FrameSizeError uint32
+
+func (e FrameSizeError) Error() string {
+	return fmt.Sprintf("Invalid frame size %d", uint32(e))
+}
+
+type UnexpectedError struct {
+	Expected Type
+	Got      Type
+}
+
+func (e UnexpectedError) Error() string {
+	return fmt.Sprintf("Unexpected message %s (expected %s)", e.Got, e.Expected)
+}
+
+// ClaimMismatchError is returned when the puzzle is not for the amount of
+// space that was claimed.
+type ClaimMismatchError struct {
+	Claimed int64
+	Puzzle  int64
+}
+
+func (e ClaimMismatchError) Error() string {
+	return fmt.Sprintf("Puzzle claim %d does not match claimed %d", e.Puzzle, e.Claimed)
+}
+
+// RemoteError is an error reported by the other side of the connection.
+type RemoteError string
+
+func (e RemoteError) Error() string {
+	return fmt.Sprintf("Remote error: %s", string(e))
+}
+
+// WriteFrame writes a single frame with the given type and payload.
+func WriteFrame(w io.Writer, t Type, payload []byte) (err error) {
+	if len(payload)+1 > MaxFrameSize {
+		return FrameSizeError(len(payload) + 1)
+	}
+
+	frame := make([]byte, 4+1+len(payload), 4+1+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)+1))
+	frame[4] = byte(t)
+	copy(frame[5:], payload)
+
+	_, err = w.Write(frame)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReadFrame reads a single frame and returns its type and payload.
+func ReadFrame(r io.Reader) (t Type, payload []byte, err error) {
+	header := make([]byte, 4, 4)
+
+	_, err = io.ReadFull(r, header)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header)
+	if size < 1 || size > MaxFrameSize {
+		return 0, nil, FrameSizeError(size)
+	}
+
+	frame := make([]byte, size, size)
+
+	_, err = io.ReadFull(r, frame)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return Type(frame[0]), frame[1:], nil
+}
+
+// Conn sends and receives JSON encoded messages as frames.
+type Conn struct {
+	rw io.ReadWriter
+}
+
+func NewConn(rw io.ReadWriter) *Conn {
+	return &Conn{
+		rw: rw,
+	}
+}
+
+// Send encodes v as JSON and writes it as a frame of type t.
+func (c *Conn) Send(t Type, v interface{}) (err error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return WriteFrame(c.rw, t, payload)
+}
+
+// Receive reads a frame of type t and decodes its payload into v. If the
+// other side sent an error message it is returned as a RemoteError.
+func (c *Conn) Receive(t Type, v interface{}) (err error) {
+	got, payload, err := ReadFrame(c.rw)
+	if err != nil {
+		return err
+	}
+
+	if got == TypeError && t != TypeError {
+		var e Error
+
+		err = json.Unmarshal(payload, &e)
+		if err != nil {
+			return err
+		}
+
+		return RemoteError(e.Message)
+	}
+
+	if got != t {
+		return UnexpectedError{
+			Expected: t,
+			Got:      got,
+		}
+	}
+
+	return json.Unmarshal(payload, v)
+}
+
+// Fail sends err to the other side as an error message. The original error is
+// returned so that it can be used in a return statement.
+func (c *Conn) Fail(err error) error {
+	// The connection is about to be abandoned so a failure to report the
+	// error is not interesting.
+	c.Send(TypeError, Error{
+		Message: err.Error(),
+	})
+
+	return err
+}
+
+// hello exchanges versions with the other side. The initiator sends first.
+func (c *Conn) hello(initiator bool) (err error) {
+	var h Hello
+
+	if initiator {
+		err = c.Send(TypeHello, Hello{Version: Version})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.Receive(TypeHello, &h)
+	if err != nil {
+		return err
+	}
+
+	if h.Version != Version {
+		return c.Fail(VersionError(h.Version))
+	}
+
+	if !initiator {
+		err = c.Send(TypeHello, Hello{Version: Version})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,319 @@
+package protocol_test
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/calebcase/pos"
+	_ "github.com/calebcase/pos/lib/aesprng"
+	"github.com/calebcase/pos/lib/protocol"
+)
+
+func newPuzzle(claim int64) (*pos.Puzzle, error) {
+	seed, err := pos.NewRandomBytes(32 + 16)
+	if err != nil {
+		return nil, err
+	}
+
+	prng, err := pos.NewPRNG("aes", seed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pos.Puzzle{
+		Claim:         claim,
+		PRNG:          prng,
+		PreseedRounds: 1,
+		IndexSize:     64,
+		SolutionSize:  8,
+	}, nil
+}
+
+type result struct {
+	verdict *pos.Verdict
+	err     error
+}
+
+// exchange runs a prover and a challenger against each other over the two
+// ends of a connection.
+func exchange(t *testing.T, proverConn, challengerConn net.Conn, prover *protocol.Prover, challenger *protocol.Challenger) (proverResult, challengerResult result) {
+	t.Helper()
+
+	done := make(chan result)
+
+	go func() {
+		defer proverConn.Close()
+
+		verdict, err := prover.Run(proverConn)
+		done <- result{verdict, err}
+	}()
+
+	verdict, err := challenger.Run(challengerConn)
+	challengerConn.Close()
+
+	return <-done, result{verdict, err}
+}
+
+func TestExchange(t *testing.T) {
+	solver, err := pos.NewMemorySolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prover, err := protocol.NewProver(64*1024, solver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenger, err := protocol.NewChallenger(newPuzzle, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proverConn, challengerConn := net.Pipe()
+
+	p, c := exchange(t, proverConn, challengerConn, prover, challenger)
+	if p.err != nil {
+		t.Fatal(p.err)
+	}
+	if c.err != nil {
+		t.Fatal(c.err)
+	}
+
+	if !c.verdict.Accepted || c.verdict.Mismatch != -1 {
+		t.Fatalf("verdict %+v, want accepted", c.verdict)
+	}
+
+	if *p.verdict != *c.verdict {
+		t.Fatalf("prover verdict %+v, challenger verdict %+v", p.verdict, c.verdict)
+	}
+}
+
+func TestExchangeLoopback(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	solver, err := pos.NewMemorySolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prover, err := protocol.NewProver(64*1024, solver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenger, err := protocol.NewChallenger(newPuzzle, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accepted := make(chan net.Conn)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			close(accepted)
+
+			return
+		}
+
+		accepted <- conn
+	}()
+
+	challengerConn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proverConn, ok := <-accepted
+	if !ok {
+		t.Fatal("accept failed")
+	}
+
+	p, c := exchange(t, proverConn, challengerConn, prover, challenger)
+	if p.err != nil {
+		t.Fatal(p.err)
+	}
+	if c.err != nil {
+		t.Fatal(c.err)
+	}
+
+	if !c.verdict.Accepted {
+		t.Fatalf("verdict %+v, want accepted", c.verdict)
+	}
+}
+
+// wrongSolver prepares nothing and answers every challenge with zeros.
+type wrongSolver struct{}
+
+func (wrongSolver) Prepare(puzzle *pos.Puzzle) error {
+	return nil
+}
+
+func (wrongSolver) Solve(puzzle *pos.Puzzle, preseedIndices []int64, mask []byte) ([]byte, error) {
+	return make([]byte, puzzle.SolutionSize, puzzle.SolutionSize), nil
+}
+
+func TestExchangeRejected(t *testing.T) {
+	prover, err := protocol.NewProver(64*1024, wrongSolver{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenger, err := protocol.NewChallenger(newPuzzle, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proverConn, challengerConn := net.Pipe()
+
+	p, c := exchange(t, proverConn, challengerConn, prover, challenger)
+	if p.err != nil {
+		t.Fatal(p.err)
+	}
+	if c.err != nil {
+		t.Fatal(c.err)
+	}
+
+	if c.verdict.Accepted || p.verdict.Accepted {
+		t.Fatalf("verdict %+v, want rejected", c.verdict)
+	}
+}
+
+// failingSolver fails to prepare.
+type failingSolver struct {
+	wrongSolver
+}
+
+func (failingSolver) Prepare(puzzle *pos.Puzzle) error {
+	return errors.New("out of space")
+}
+
+func TestExchangeProverError(t *testing.T) {
+	prover, err := protocol.NewProver(64*1024, failingSolver{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenger, err := protocol.NewChallenger(newPuzzle, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proverConn, challengerConn := net.Pipe()
+
+	p, c := exchange(t, proverConn, challengerConn, prover, challenger)
+	if p.err == nil || p.err.Error() != "out of space" {
+		t.Fatalf("prover error %v, want out of space", p.err)
+	}
+
+	if c.err != protocol.RemoteError("out of space") {
+		t.Fatalf("challenger error %v, want remote error", c.err)
+	}
+}
+
+func TestExchangeClaimMismatch(t *testing.T) {
+	solver, err := pos.NewMemorySolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prover, err := protocol.NewProver(64*1024, solver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenger, err := protocol.NewChallenger(func(claim int64) (*pos.Puzzle, error) {
+		return newPuzzle(claim * 2)
+	}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proverConn, challengerConn := net.Pipe()
+
+	_, c := exchange(t, proverConn, challengerConn, prover, challenger)
+
+	expected := protocol.ClaimMismatchError{Claimed: 64 * 1024, Puzzle: 128 * 1024}
+	if c.err != expected {
+		t.Fatalf("challenger error %v, want %v", c.err, expected)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := protocol.WriteFrame(&buf, protocol.TypeSolution, []byte(`{"solution":"AA=="}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	typ, payload, err := protocol.ReadFrame(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if typ != protocol.TypeSolution || string(payload) != `{"solution":"AA=="}` {
+		t.Fatalf("frame %s %q", typ, payload)
+	}
+
+	err = protocol.WriteFrame(&buf, protocol.TypeSolution, make([]byte, protocol.MaxFrameSize, protocol.MaxFrameSize))
+	if _, ok := err.(protocol.FrameSizeError); !ok {
+		t.Fatalf("error %v, want FrameSizeError", err)
+	}
+
+	_, _, err = protocol.ReadFrame(bytes.NewReader([]byte{0, 0, 0, 0}))
+	if err != protocol.FrameSizeError(0) {
+		t.Fatalf("error %v, want FrameSizeError", err)
+	}
+}
+
+func TestVersionMismatch(t *testing.T) {
+	proverConn, challengerConn := net.Pipe()
+	defer challengerConn.Close()
+
+	solver, err := pos.NewMemorySolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prover, err := protocol.NewProver(64*1024, solver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+
+	go func() {
+		defer proverConn.Close()
+
+		_, err := prover.Run(proverConn)
+		done <- err
+	}()
+
+	c := protocol.NewConn(challengerConn)
+
+	err = c.Send(protocol.TypeHello, protocol.Hello{Version: protocol.Version + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var h protocol.Hello
+
+	err = c.Receive(protocol.TypeHello, &h)
+	if err != protocol.RemoteError(protocol.VersionError(protocol.Version+1).Error()) {
+		t.Fatalf("error %v, want remote version error", err)
+	}
+
+	err = <-done
+	if err != protocol.VersionError(protocol.Version+1) {
+		t.Fatalf("prover error %v, want version error", err)
+	}
+}
@@ -0,0 +1,88 @@
+package protocol
+
+import (
+	"io"
+
+	"github.com/calebcase/pos"
+)
+
+// Prover runs the prover side of the exchange for a single claim.
+type Prover struct {
+	Claim  int64      // The amount of space in bytes to claim.
+	Solver pos.Solver // The solver used to prepare and solve the puzzle.
+}
+
+func NewProver(claim int64, solver pos.Solver) (*Prover, error) {
+	return &Prover{
+		Claim:  claim,
+		Solver: solver,
+	}, nil
+}
+
+// Run performs the exchange with the challenger on rw and returns the
+// challenger's verdict.
+func (p *Prover) Run(rw io.ReadWriter) (verdict *pos.Verdict, err error) {
+	c := NewConn(rw)
+
+	err = c.hello(false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.Send(TypeClaim, Claim{Claim: p.Claim})
+	if err != nil {
+		return nil, err
+	}
+
+	var puzzle pos.Puzzle
+
+	err = c.Receive(TypePuzzle, &puzzle)
+	if err != nil {
+		return nil, err
+	}
+
+	err = puzzle.Check()
+	if err != nil {
+		return nil, c.Fail(err)
+	}
+
+	if puzzle.Claim != p.Claim {
+		return nil, c.Fail(ClaimMismatchError{p.Claim, puzzle.Claim})
+	}
+
+	err = p.Solver.Prepare(&puzzle)
+	if err != nil {
+		return nil, c.Fail(err)
+	}
+
+	err = c.Send(TypePrepared, Prepared{})
+	if err != nil {
+		return nil, err
+	}
+
+	var challenge Challenge
+
+	err = c.Receive(TypeChallenge, &challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	solution, err := p.Solver.Solve(&puzzle, challenge.PreseedIndices, challenge.Mask)
+	if err != nil {
+		return nil, c.Fail(err)
+	}
+
+	err = c.Send(TypeSolution, Solution{Solution: solution})
+	if err != nil {
+		return nil, err
+	}
+
+	var v Verdict
+
+	err = c.Receive(TypeVerdict, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v.Verdict, nil
+}
@@ -0,0 +1,60 @@
+package pos
+
+import "io"
+
+// MemorySolver is a solver that keeps the claimed space in memory. It is
+// suited to small claims, RAM disk provers and as a best case baseline when
+// comparing solvers. Its solutions are identical to those of DiskSolver.
+type MemorySolver struct {
+	Image []byte // The prepared claim.
+}
+
+var _ Solver = (*MemorySolver)(nil)
+
+func NewMemorySolver() (*MemorySolver, error) {
+	return &MemorySolver{}, nil
+}
+
+func (s *MemorySolver) Prepare(puzzle *Puzzle) (err error) {
+	if puzzle.Claim < 0 {
+		return OffsetError(puzzle.Claim)
+	}
+
+	prng, err := puzzle.PRNG.Clone()
+	if err != nil {
+		return err
+	}
+
+	if int64(cap(s.Image)) >= puzzle.Claim {
+		s.Image = s.Image[:puzzle.Claim]
+	} else {
+		s.Image = make([]byte, puzzle.Claim, puzzle.Claim)
+	}
+
+	_, err = io.ReadFull(prng, s.Image)
+	if err != nil {
+		s.Image = nil
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *MemorySolver) fromIndices(indices []int64) (value []byte, err error) {
+	value = make([]byte, len(indices), len(indices))
+
+	for i, index := range indices {
+		if index < 0 || index >= int64(len(s.Image)) {
+			return nil, io.EOF
+		}
+
+		value[i] = s.Image[index]
+	}
+
+	return value, nil
+}
+
+func (s *MemorySolver) Solve(puzzle *Puzzle, preseedIndices []int64, mask []byte) (solution []byte, err error) {
+	return solve(puzzle, preseedIndices, mask, s.fromIndices)
+}
@@ -0,0 +1,113 @@
+package pos
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// ErrMmapUnsupported is returned when memory mapping is not available on the
+// current platform.
+var ErrMmapUnsupported = errors.New("mmap is not supported on this platform")
+
+// MmapDiskSolver is a DiskSolver that reads indices directly from a memory
+// mapping of the image file instead of issuing a seek and read per index. If
+// the image cannot be mapped it falls back to the DiskSolver.
+type MmapDiskSolver struct {
+	Offset int64 // The offset of the claimed space in the image.
+
+	file *os.File
+	disk *DiskSolver
+	data []byte
+}
+
+var _ Solver = (*MmapDiskSolver)(nil)
+
+func NewMmapDiskSolver(file *os.File) (*MmapDiskSolver, error) {
+	disk, err := NewDiskSolver(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MmapDiskSolver{
+		file: file,
+		disk: disk,
+	}, nil
+}
+
+func (s *MmapDiskSolver) Prepare(puzzle *Puzzle) (err error) {
+	err = s.Close()
+	if err != nil {
+		return err
+	}
+
+	s.disk.Offset = s.Offset
+
+	return s.disk.Prepare(puzzle)
+}
+
+// Close unmaps the image. The image file is not closed.
+func (s *MmapDiskSolver) Close() (err error) {
+	if s.data == nil {
+		return nil
+	}
+
+	err = munmap(s.data)
+	if err != nil {
+		return err
+	}
+
+	s.data = nil
+
+	return nil
+}
+
+// mapImage maps up to claim bytes of the image file starting at the offset.
+// The offset must be a multiple of the page size.
+func (s *MmapDiskSolver) mapImage(claim int64) (err error) {
+	if s.data != nil {
+		return nil
+	}
+
+	info, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	size := info.Size() - s.Offset
+	if size > claim {
+		size = claim
+	}
+
+	s.data, err = mmap(s.file, s.Offset, size)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *MmapDiskSolver) fromIndices(indices []int64) (value []byte, err error) {
+	value = make([]byte, len(indices), len(indices))
+
+	for i, index := range indices {
+		if index < 0 || index >= int64(len(s.data)) {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		value[i] = s.data[index]
+	}
+
+	return value, nil
+}
+
+func (s *MmapDiskSolver) Solve(puzzle *Puzzle, preseedIndices []int64, mask []byte) (solution []byte, err error) {
+	err = s.mapImage(puzzle.Claim)
+	if err != nil {
+		s.disk.Offset = s.Offset
+
+		return s.disk.Solve(puzzle, preseedIndices, mask)
+	}
+
+	return solve(puzzle, preseedIndices, mask, s.fromIndices)
+}
@@ -0,0 +1,30 @@
+//go:build linux
+// +build linux
+
+package pos
+
+import (
+	"os"
+	"syscall"
+)
+
+func mmap(file *os.File, offset, size int64) (data []byte, err error) {
+	data, err = syscall.Mmap(int(file.Fd()), offset, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+
+	// Solving reads scattered indices so read ahead only wastes I/O.
+	err = syscall.Madvise(data, syscall.MADV_RANDOM)
+	if err != nil {
+		syscall.Munmap(data)
+
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func munmap(data []byte) error {
+	return syscall.Munmap(data)
+}
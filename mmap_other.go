@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package pos
+
+import "os"
+
+func mmap(file *os.File, offset, size int64) (data []byte, err error) {
+	return nil, ErrMmapUnsupported
+}
+
+func munmap(data []byte) error {
+	return ErrMmapUnsupported
+}
@@ -0,0 +1,194 @@
+package pos
+
+import (
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// ParallelStreamSolver is a StreamSolver that splits generation of the PRNG
+// stream across workers. Each worker generates disjoint ranges of the claim
+// and collects the indices that fall inside them. This requires the puzzle's
+// PRNG to be a SegmentedPRNG, in which case the ranges are its segments, or a
+// SeekablePRNG; other PRNGs are generated serially.
+type ParallelStreamSolver struct {
+	Workers   int   // The number of workers generating the stream.
+	BytesRead int64 // The number of PRNG bytes generated.
+}
+
+var _ Solver = (*ParallelStreamSolver)(nil)
+
+// NewParallelStreamSolver returns a solver using the given number of workers.
+// If workers is less than 1 the number of CPUs is used.
+func NewParallelStreamSolver(workers int) (*ParallelStreamSolver, error) {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	return &ParallelStreamSolver{
+		Workers: workers,
+	}, nil
+}
+
+func (s *ParallelStreamSolver) Prepare(puzzle *Puzzle) (err error) {
+	return nil
+}
+
+// position is an index and its position in the list of requested indices.
+type position struct {
+	i     int
+	index int64
+}
+
+func (s *ParallelStreamSolver) fromIndices(puzzle *Puzzle, indices []int64) (value []byte, err error) {
+	prng, err := puzzle.PRNG.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	const lastSize = 1024
+
+	// Each range of the claim is generated by a PRNG started at its
+	// beginning.
+	var size int64
+	var start func(k int64) (PRNG, error)
+
+	switch p := prng.(type) {
+	case SegmentedPRNG:
+		size = p.SegmentSize()
+		start = p.Segment
+	case SeekablePRNG:
+		// Split the claim into one range per worker aligned to the block
+		// size.
+		size = (puzzle.Claim + int64(s.Workers) - 1) / int64(s.Workers)
+		size = (size + lastSize - 1) / lastSize * lastSize
+
+		start = func(k int64) (PRNG, error) {
+			prng, err := puzzle.PRNG.Clone()
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = prng.(SeekablePRNG).Seek(k*size, io.SeekStart)
+			if err != nil {
+				return nil, err
+			}
+
+			return prng, nil
+		}
+	}
+
+	if start == nil || s.Workers < 2 {
+		serial := &StreamSolver{}
+
+		value, err = serial.fromIndices(puzzle, indices)
+		atomic.AddInt64(&s.BytesRead, serial.BytesRead)
+
+		return value, err
+	}
+
+	buckets := map[int64][]position{}
+	for i, index := range indices {
+		// Indices outside of the claim are left as zero like StreamSolver.
+		if index < 0 || index >= puzzle.Claim {
+			continue
+		}
+
+		k := index / size
+		buckets[k] = append(buckets[k], position{i, index})
+	}
+
+	value = make([]byte, len(indices), len(indices))
+
+	ranges := make(chan int64)
+	errs := make([]error, s.Workers, s.Workers)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < s.Workers; w++ {
+		wg.Add(1)
+
+		go func(w int) {
+			defer wg.Done()
+
+			for k := range ranges {
+				if errs[w] != nil {
+					continue
+				}
+
+				prng, err := start(k)
+				if err != nil {
+					errs[w] = err
+
+					continue
+				}
+
+				end := (k + 1) * size
+				if end > puzzle.Claim {
+					end = puzzle.Claim
+				}
+
+				errs[w] = s.generate(prng, k*size, end, buckets[k], value)
+			}
+		}(w)
+	}
+
+	for k := int64(0); k*size < puzzle.Claim; k++ {
+		ranges <- k
+	}
+	close(ranges)
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+// generate reads the stream of prng from start to end of the claim and stores
+// the bytes at the given positions in value.
+func (s *ParallelStreamSolver) generate(prng PRNG, start, end int64, positions []position, value []byte) (err error) {
+	sort.Slice(positions, func(a, b int) bool {
+		return positions[a].index < positions[b].index
+	})
+
+	const lastSize = 1024
+	last := make([]byte, lastSize, lastSize)
+
+	var read int64
+	defer func() {
+		atomic.AddInt64(&s.BytesRead, read)
+	}()
+
+	for i := start; i < end; i += lastSize {
+		size := int64(lastSize)
+		if end-i < size {
+			size = end - i
+		}
+
+		n, err := io.ReadFull(prng, last[:size])
+		read += int64(n)
+		if err != nil {
+			return err
+		}
+
+		for len(positions) > 0 && positions[0].index < i+size {
+			value[positions[0].i] = last[positions[0].index-i]
+			positions = positions[1:]
+		}
+	}
+
+	return nil
+}
+
+func (s *ParallelStreamSolver) Solve(puzzle *Puzzle, preseedIndices []int64, mask []byte) (solution []byte, err error) {
+	return solve(puzzle, preseedIndices, mask, func(indices []int64) ([]byte, error) {
+		return s.fromIndices(puzzle, indices)
+	})
+}
@@ -0,0 +1,47 @@
+package pos_test
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/calebcase/pos"
+)
+
+func BenchmarkParallelStreamSolver(b *testing.B) {
+	puzzle := newPuzzle(b, "aes-ctr", 64*1024*1024)
+	preseedIndices, mask := newChallenge(b, puzzle)
+
+	streamSolver, err := pos.NewStreamSolver()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	expected, err := streamSolver.Solve(puzzle, preseedIndices, mask)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for workers := 1; workers <= runtime.NumCPU(); workers *= 2 {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(puzzle.Claim)
+
+			for i := 0; i < b.N; i++ {
+				solver, err := pos.NewParallelStreamSolver(workers)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				actual, err := solver.Solve(puzzle, preseedIndices, mask)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				if !bytes.Equal(actual, expected) {
+					b.Fatalf("solution %x, want %x", actual, expected)
+				}
+			}
+		})
+	}
+}
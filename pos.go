@@ -2,6 +2,7 @@ package pos
 
 import (
 	"crypto/rand"
+	"encoding/json"
 	"io"
 	"math"
 	"math/big"
@@ -22,6 +23,13 @@ type PRNG interface {
 	GetSeed() []byte
 }
 
+// serialPRNG is the JSON encoding shared by PRNG implementations. The type
+// is the name the PRNG is registered under with RegisterPRNG.
+type serialPRNG struct {
+	Type string `json:"type"`
+	Seed []byte `json:"seed"`
+}
+
 // NewRandomBytes returns random bytes of the given size.
 func NewRandomBytes(size int) ([]byte, error) {
 	b := make([]byte, size)
@@ -44,7 +52,50 @@ type Puzzle struct {
 	SolutionSize  int64 `json:"solution_size"`  // The size in bytes of the solution.
 }
 
+// UnmarshalJSON decodes a puzzle. The PRNG is created with NewPRNG using the
+// type and seed it was encoded with, so the package implementing it must be
+// imported for its registration side effect.
+func (p *Puzzle) UnmarshalJSON(b []byte) (err error) {
+	type puzzle Puzzle
+
+	var s struct {
+		*puzzle
+
+		PRNG *serialPRNG `json:"prng"`
+	}
+
+	s.puzzle = (*puzzle)(p)
+
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+
+	if s.PRNG == nil {
+		p.PRNG = nil
+
+		return nil
+	}
+
+	p.PRNG, err = NewPRNG(s.PRNG.Type, s.PRNG.Seed)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// selectIndices returns exactly n indices into the claim drawn from a PRNG
+// seeded with seed.
 func (p *Puzzle) selectIndices(n int64, seed []byte) (indices []int64, err error) {
+	if p.IndexSize <= 0 || p.IndexSize > MaxIndexSize {
+		return nil, IndexSizeError(p.IndexSize)
+	}
+
+	if n < 0 {
+		return nil, IndexCountError(n)
+	}
+
 	prng, err := p.PRNG.New(seed)
 	if err != nil {
 		return nil, err
@@ -57,7 +108,7 @@ func (p *Puzzle) selectIndices(n int64, seed []byte) (indices []int64, err error
 
 	indices = make([]int64, 0, n)
 
-	for j := int64(0); j < p.Claim && int64(len(indices)) < n; j += p.IndexSize {
+	for int64(len(indices)) < n {
 		_, err := io.ReadFull(prng, index)
 		if err != nil {
 			return nil, err
@@ -75,6 +126,10 @@ func (p *Puzzle) selectIndices(n int64, seed []byte) (indices []int64, err error
 // PreseedIndices computes the offsets of the preseed bytes. Read the byte at
 // each offset to create a preseed.
 func (p *Puzzle) PreseedIndices(n int64, seed []byte) (indices []int64, err error) {
+	if n < 1 {
+		return nil, IndexCountError(n)
+	}
+
 	indices, err = p.selectIndices(n-1, seed)
 	if err != nil {
 		return nil, err
@@ -88,8 +143,16 @@ func (p *Puzzle) PreseedIndices(n int64, seed []byte) (indices []int64, err erro
 // SolutionIndices computes the offsets of the solution bytes. Read the byte at
 // each offset to create a solution.
 func (p *Puzzle) SolutionIndices(preseed, mask []byte) (indices []int64, err error) {
+	if len(preseed) != len(mask) {
+		return nil, MaskSizeError{len(mask), len(preseed)}
+	}
+
+	if p.SolutionSize > MaxSolutionSize {
+		return nil, SolutionSizeError(p.SolutionSize)
+	}
+
 	seed := make([]byte, len(mask), len(mask))
-	for i, _ := range preseed {
+	for i := range preseed {
 		seed[i] = preseed[i] ^ mask[i]
 	}
 
@@ -138,3 +201,47 @@ type Solver interface {
 	Prepare(puzzle *Puzzle) error
 	Solve(puzzle *Puzzle, preseedIndices []int64, mask []byte) (solution []byte, err error)
 }
+
+// solve runs the solve phase of a puzzle using lookup to read the bytes at a
+// set of indices. It is shared by the solver implementations, which differ
+// only in how the bytes are retrieved. The puzzle and challenge are checked
+// with ValidateChallenge first.
+func solve(puzzle *Puzzle, preseedIndices []int64, mask []byte, lookup func(indices []int64) ([]byte, error)) (solution []byte, err error) {
+	err = puzzle.ValidateChallenge(preseedIndices, mask)
+	if err != nil {
+		return nil, err
+	}
+
+	var preseed []byte
+
+	// First Pass: Read all preseed indices and construct the preseed.
+	for i := int64(0); i < puzzle.PreseedRounds; i++ {
+		preseed, err = lookup(preseedIndices)
+		if err != nil {
+			return nil, err
+		}
+
+		preseedIndices, err = puzzle.PreseedIndices(int64(len(preseedIndices)), preseed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	preseed, err = lookup(preseedIndices)
+	if err != nil {
+		return nil, err
+	}
+
+	// Second Pass: Read all the solution indices and construct the solution.
+	solutionIndices, err := puzzle.SolutionIndices(preseed, mask)
+	if err != nil {
+		return nil, err
+	}
+
+	solution, err = lookup(solutionIndices)
+	if err != nil {
+		return nil, err
+	}
+
+	return solution, nil
+}
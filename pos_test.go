@@ -0,0 +1,97 @@
+package pos_test
+
+import (
+	"testing"
+
+	"github.com/calebcase/pos"
+
+	// Register the PRNG types used by the tests.
+	_ "github.com/calebcase/pos/lib/aesctrprng"
+	_ "github.com/calebcase/pos/lib/aesprng"
+	_ "github.com/calebcase/pos/lib/chachaprng"
+)
+
+// newPuzzle returns a puzzle for the claim using a PRNG of the given type
+// with a fixed seed so that tests are deterministic.
+func newPuzzle(t testing.TB, prngType string, claim int64) *pos.Puzzle {
+	t.Helper()
+
+	size, err := pos.PRNGSeedSize(prngType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seed := make([]byte, size, size)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+
+	prng, err := pos.NewPRNG(prngType, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &pos.Puzzle{
+		Claim:         claim,
+		PRNG:          prng,
+		PreseedRounds: 1,
+		IndexSize:     64,
+		SolutionSize:  8,
+	}
+}
+
+// newChallenge returns fixed preseed indices and mask for the puzzle.
+func newChallenge(t testing.TB, puzzle *pos.Puzzle) (preseedIndices []int64, mask []byte) {
+	t.Helper()
+
+	size := len(puzzle.PRNG.GetSeed())
+
+	seed := make([]byte, size, size)
+	mask = make([]byte, size, size)
+
+	for i := range seed {
+		seed[i] = byte(255 - i)
+		mask[i] = byte(i * 7)
+	}
+
+	preseedIndices, err := puzzle.PreseedIndices(int64(size), seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return preseedIndices, mask
+}
+
+// TestIndexCount checks that exactly the requested number of indices is
+// selected when they need more bytes than the claim.
+func TestIndexCount(t *testing.T) {
+	puzzle := newPuzzle(t, "aes", 10)
+	puzzle.IndexSize = 64
+	puzzle.SolutionSize = 100
+
+	size := len(puzzle.PRNG.GetSeed())
+
+	indices, err := puzzle.SolutionIndices(make([]byte, size, size), make([]byte, size, size))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if int64(len(indices)) != puzzle.SolutionSize {
+		t.Fatalf("%d solution indices, want %d", len(indices), puzzle.SolutionSize)
+	}
+
+	indices, err = puzzle.PreseedIndices(int64(size), make([]byte, size, size))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(indices) != size {
+		t.Fatalf("%d preseed indices, want %d", len(indices), size)
+	}
+
+	for _, index := range indices {
+		if index < 0 || index >= puzzle.Claim {
+			t.Fatalf("index %d is outside of the claim", index)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package pos
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// Proof is a non-interactive proof of space. Instead of receiving a challenge
+// from a challenger, the prover derives it from the puzzle and a public
+// beacon value, such as a block hash or timestamp, with DeriveChallenge.
+// Anyone can check the proof with Verifier.VerifyProof.
+//
+// A proof only shows that the solution was computed after the beacon was
+// known. Without a challenger timing the response, the verifier must also
+// check that the proof was published soon enough after the beacon that a
+// prover without the space could not have computed it from the PRNG stream.
+type Proof struct {
+	Puzzle   *Puzzle `json:"puzzle"`   // The puzzle the prover prepared.
+	Beacon   []byte  `json:"beacon"`   // The public value the challenge is derived from.
+	Solution []byte  `json:"solution"` // The solution to the derived challenge.
+}
+
+// DeriveChallenge derives the preseed indices and mask of a challenge from
+// the puzzle's ID and a beacon. The same puzzle and beacon always give the
+// same challenge. Puzzles with a SeekablePRNG are rejected with a
+// SeekablePRNGError.
+func DeriveChallenge(puzzle *Puzzle, beacon []byte) (preseedIndices []int64, mask []byte, err error) {
+	err = puzzle.Check()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = CheckNotSeekable(puzzle.PRNG)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, err := puzzle.ID()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	size := len(puzzle.PRNG.GetSeed())
+
+	seed := expand(id, beacon, "preseed", size)
+
+	preseedIndices, err = puzzle.PreseedIndices(int64(size), seed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mask = expand(id, beacon, "mask", size)
+
+	return preseedIndices, mask, nil
+}
+
+// expand returns size bytes derived from the puzzle ID, beacon and label. The
+// bytes are the concatenation of SHA-256 hashes of the inputs and a counter.
+// The beacon's length is included so that the inputs are unambiguous.
+func expand(id PuzzleID, beacon []byte, label string, size int) []byte {
+	out := make([]byte, 0, size+sha256.Size)
+
+	var n [8]byte
+
+	for counter := uint64(0); len(out) < size; counter++ {
+		h := sha256.New()
+
+		h.Write([]byte("pos challenge " + label))
+		h.Write(id[:])
+
+		binary.BigEndian.PutUint64(n[:], uint64(len(beacon)))
+		h.Write(n[:])
+		h.Write(beacon)
+
+		binary.BigEndian.PutUint64(n[:], counter)
+		h.Write(n[:])
+
+		out = h.Sum(out)
+	}
+
+	return out[:size]
+}
+
+// Prove solves the challenge derived from the puzzle and beacon with a
+// prepared solver and returns the proof.
+func Prove(solver Solver, puzzle *Puzzle, beacon []byte) (proof *Proof, err error) {
+	preseedIndices, mask, err := DeriveChallenge(puzzle, beacon)
+	if err != nil {
+		return nil, err
+	}
+
+	solution, err := solver.Solve(puzzle, preseedIndices, mask)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Proof{
+		Puzzle:   puzzle,
+		Beacon:   beacon,
+		Solution: solution,
+	}, nil
+}
@@ -0,0 +1,82 @@
+package pos_test
+
+import (
+	"testing"
+
+	"github.com/calebcase/pos"
+)
+
+func TestProof(t *testing.T) {
+	puzzle := newPuzzle(t, "aes", 64*1024)
+	beacon := []byte("beacon")
+
+	solver, err := pos.NewMemorySolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = solver.Prepare(puzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := pos.Prove(solver, puzzle, beacon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := pos.NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := verifier.VerifyProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ok {
+		t.Fatalf("proof rejected at %d", verifier.Mismatch)
+	}
+
+	// The challenge depends on the beacon.
+	proof.Beacon = []byte("other beacon")
+
+	ok, err = verifier.VerifyProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok {
+		t.Fatal("proof accepted for another beacon")
+	}
+}
+
+func TestProofSeekable(t *testing.T) {
+	puzzle := newPuzzle(t, "aes-ctr", 64*1024)
+	beacon := []byte("beacon")
+
+	solver, err := pos.NewStreamSolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = pos.Prove(solver, puzzle, beacon)
+	if err != pos.SeekablePRNGError("aes-ctr") {
+		t.Fatalf("error %v, want %v", err, pos.SeekablePRNGError("aes-ctr"))
+	}
+
+	verifier, err := pos.NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = verifier.VerifyProof(&pos.Proof{
+		Puzzle:   puzzle,
+		Beacon:   beacon,
+		Solution: make([]byte, puzzle.SolutionSize, puzzle.SolutionSize),
+	})
+	if err != pos.SeekablePRNGError("aes-ctr") {
+		t.Fatalf("error %v, want %v", err, pos.SeekablePRNGError("aes-ctr"))
+	}
+}
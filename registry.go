@@ -0,0 +1,116 @@
+package pos
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// PRNGFactory creates a PRNG initialized with the given seed.
+type PRNGFactory func(seed []byte) (prng PRNG, err error)
+
+type UnknownPRNGError string
+
+func (e UnknownPRNGError) Error() string {
+	return fmt.Sprintf("Unknown PRNG type %q", string(e))
+}
+
+type registration struct {
+	seedSize int
+	factory  PRNGFactory
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]registration)
+)
+
+// RegisterPRNG makes a PRNG available by the given type name. seedSize is the
+// size of the random seed generated for the PRNG when none is given. A PRNG
+// must marshal to JSON as an object with a "type" field set to this name and a
+// "seed" field holding its seed to be decoded as part of a Puzzle. It is
+// intended to be called from the init function of the package implementing
+// the PRNG. If RegisterPRNG is called twice with the same name, if seedSize is
+// less than 1 or if factory is nil, it panics.
+func RegisterPRNG(name string, seedSize int, factory PRNGFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("pos: RegisterPRNG factory is nil")
+	}
+
+	if seedSize < 1 {
+		panic("pos: RegisterPRNG seed size is less than 1 for " + name)
+	}
+
+	if _, dup := registry[name]; dup {
+		panic("pos: RegisterPRNG called twice for " + name)
+	}
+
+	registry[name] = registration{
+		seedSize: seedSize,
+		factory:  factory,
+	}
+}
+
+// NewPRNG creates a PRNG of the registered type name initialized with the
+// given seed.
+func NewPRNG(name string, seed []byte) (prng PRNG, err error) {
+	registryMu.RLock()
+	r, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, UnknownPRNGError(name)
+	}
+
+	return r.factory(seed)
+}
+
+// PRNGSeedSize returns the seed size the PRNG type name was registered with.
+func PRNGSeedSize(name string) (size int, err error) {
+	registryMu.RLock()
+	r, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return 0, UnknownPRNGError(name)
+	}
+
+	return r.seedSize, nil
+}
+
+// PRNGTypes returns a sorted list of the registered PRNG type names.
+func PRNGTypes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// PRNGType returns the registered type name of prng. It is taken from the
+// "type" field of the PRNG's JSON encoding.
+func PRNGType(prng PRNG) (name string, err error) {
+	b, err := json.Marshal(prng)
+	if err != nil {
+		return "", err
+	}
+
+	var s serialPRNG
+
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return "", err
+	}
+
+	return s.Type, nil
+}
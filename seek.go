@@ -0,0 +1,130 @@
+package pos
+
+import (
+	"fmt"
+	"io"
+)
+
+// A type implementing the SeekablePRNG interface can compute the byte at any
+// offset of its stream without generating the bytes before it. Seeking is
+// relative to the start of the stream as produced by Clone. Seeking relative
+// to the end of the stream is not supported.
+//
+// SECURITY: The proof of space relies on the prover being unable to recompute
+// the bytes at the solution indices faster than reading them from storage.
+// With a seekable PRNG a prover can compute each requested byte directly and
+// answer challenges without storing anything. Seekable PRNGs are useful for
+// testing, building fixtures and parallel generation, but puzzles meant to
+// prove storage must use a PRNG whose stream can only be generated serially
+// (such as aesprng).
+type SeekablePRNG interface {
+	PRNG
+	io.Seeker
+}
+
+// SeekablePRNGError is returned when a puzzle with a SeekablePRNG is used to
+// prove storage. It is the PRNG's type name.
+type SeekablePRNGError string
+
+func (e SeekablePRNGError) Error() string {
+	return fmt.Sprintf("PRNG type %q is seekable and cannot prove storage", string(e))
+}
+
+// CheckNotSeekable returns a SeekablePRNGError if prng is a SeekablePRNG.
+func CheckNotSeekable(prng PRNG) error {
+	if _, ok := prng.(SeekablePRNG); !ok {
+		return nil
+	}
+
+	name, err := PRNGType(prng)
+	if err != nil {
+		return err
+	}
+
+	return SeekablePRNGError(name)
+}
+
+type OffsetError int64
+
+func (e OffsetError) Error() string {
+	return fmt.Sprintf("Invalid offset %d", int64(e))
+}
+
+type WhenceError int
+
+func (e WhenceError) Error() string {
+	return fmt.Sprintf("Invalid whence %d", int(e))
+}
+
+// SeekOffset resolves the target of a Seek call on a stream currently at
+// position current. It is intended for use by SeekablePRNG implementations.
+func SeekOffset(current, offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += current
+	default:
+		return 0, WhenceError(whence)
+	}
+
+	if offset < 0 {
+		return 0, OffsetError(offset)
+	}
+
+	return offset, nil
+}
+
+// seekIndices reads the bytes at the given indices directly from a seekable
+// PRNG. It returns the bytes and the number of PRNG bytes generated.
+func seekIndices(prng SeekablePRNG, indices []int64) (value []byte, read int64, err error) {
+	value = make([]byte, len(indices), len(indices))
+
+	tmp := make([]byte, 1, 1)
+
+	for i, index := range indices {
+		_, err := prng.Seek(index, io.SeekStart)
+		if err != nil {
+			return nil, read, err
+		}
+
+		n, err := io.ReadFull(prng, tmp)
+		read += int64(n)
+		if err != nil {
+			return nil, read, err
+		}
+
+		value[i] = tmp[0]
+	}
+
+	return value, read, nil
+}
+
+// skip advances prng by n bytes, seeking directly if it is a SeekablePRNG. It
+// returns the number of PRNG bytes generated to do so.
+func skip(prng PRNG, n int64) (read int64, err error) {
+	if seekable, ok := prng.(SeekablePRNG); ok {
+		_, err = seekable.Seek(n, io.SeekCurrent)
+
+		return 0, err
+	}
+
+	const lastSize = 1024
+	last := make([]byte, lastSize, lastSize)
+
+	for n > 0 {
+		size := int64(lastSize)
+		if n < size {
+			size = n
+		}
+
+		c, err := io.ReadFull(prng, last[:size])
+		read += int64(c)
+		if err != nil {
+			return read, err
+		}
+
+		n -= size
+	}
+
+	return read, nil
+}
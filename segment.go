@@ -0,0 +1,27 @@
+package pos
+
+import "errors"
+
+// ErrNotSegmented is returned when a stream must be generated in parallel but
+// the PRNG is neither a SeekablePRNG nor a SegmentedPRNG.
+var ErrNotSegmented = errors.New("PRNG cannot be generated in parallel")
+
+// A type implementing the SegmentedPRNG interface produces its stream as a
+// sequence of segments of SegmentSize bytes. Each segment is generated
+// serially, but any segment can be started without generating the segments
+// before it, so a stream can be prepared by several workers at once.
+//
+// SECURITY: A prover can recompute the byte at any offset by generating at
+// most SegmentSize bytes from the start of its segment, rather than the whole
+// stream before it. The segment size must be large enough that doing so for
+// each solution index is slower than reading the bytes from storage.
+type SegmentedPRNG interface {
+	PRNG
+
+	// SegmentSize returns the size in bytes of each segment.
+	SegmentSize() int64
+
+	// Segment returns a PRNG whose stream starts at the beginning of
+	// segment k and continues through the segments after it.
+	Segment(k int64) (PRNG, error)
+}
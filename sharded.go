@@ -0,0 +1,426 @@
+package pos
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Layouts for spreading a claim across shards.
+const (
+	LayoutConcat = "concat" // Each shard holds a contiguous range of the claim.
+	LayoutStripe = "stripe" // Stripes of the claim are assigned to shards round robin.
+)
+
+// ManifestVersion is the version of the manifest format.
+const ManifestVersion = 1
+
+// ShardHeadSize is the number of bytes at the start of each shard that are
+// hashed to identify it.
+const ShardHeadSize = 4096
+
+type LayoutError string
+
+func (e LayoutError) Error() string {
+	return fmt.Sprintf("Invalid layout %q", string(e))
+}
+
+type ShardCountError int
+
+func (e ShardCountError) Error() string {
+	return fmt.Sprintf("Invalid number of shards %d", int(e))
+}
+
+// ShardMismatchError is returned when a shard does not hold the data the
+// manifest describes for it, such as when shards are given out of order.
+type ShardMismatchError int
+
+func (e ShardMismatchError) Error() string {
+	return fmt.Sprintf("Shard %d does not match the manifest", int(e))
+}
+
+// Shard describes one backing file of a sharded image.
+type Shard struct {
+	Path string `json:"path"` // The path of the backing file.
+	Size int64  `json:"size"` // The number of bytes of the claim stored in the shard.
+	Head []byte `json:"head"` // The SHA-256 of the first ShardHeadSize bytes of the shard.
+}
+
+// Manifest describes how a claim is laid out across shards and identifies the
+// puzzle it was prepared for.
+type Manifest struct {
+	Version    int     `json:"version"`
+	Layout     string  `json:"layout"`
+	StripeSize int64   `json:"stripe_size,omitempty"`
+	Claim      int64   `json:"claim"`
+	PRNGType   string  `json:"prng_type"`
+	SeedHash   []byte  `json:"seed_hash"`
+	Shards     []Shard `json:"shards"`
+}
+
+// NewManifest returns a manifest spreading the claim of the puzzle across
+// the given shard paths. With LayoutConcat the claim is split evenly into
+// contiguous ranges. With LayoutStripe it is split into stripes of the given
+// size assigned to the shards in turn.
+func NewManifest(puzzle *Puzzle, layout string, stripeSize int64, paths []string) (*Manifest, error) {
+	if len(paths) < 1 {
+		return nil, ShardCountError(len(paths))
+	}
+
+	h, err := NewImageHeader(puzzle)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		Version:  ManifestVersion,
+		Layout:   layout,
+		Claim:    puzzle.Claim,
+		PRNGType: h.PRNGType,
+		SeedHash: h.SeedHash[:],
+		Shards:   make([]Shard, len(paths), len(paths)),
+	}
+
+	n := int64(len(paths))
+
+	switch layout {
+	case LayoutConcat:
+		size := (puzzle.Claim + n - 1) / n
+
+		for i, path := range paths {
+			start := int64(i) * size
+			end := start + size
+			if end > puzzle.Claim {
+				end = puzzle.Claim
+			}
+			if start > end {
+				start = end
+			}
+
+			m.Shards[i] = Shard{Path: path, Size: end - start}
+		}
+	case LayoutStripe:
+		if stripeSize <= 0 {
+			return nil, BlockSizeError(stripeSize)
+		}
+
+		m.StripeSize = stripeSize
+
+		stripes := (puzzle.Claim + stripeSize - 1) / stripeSize
+
+		for i, path := range paths {
+			// Every shard gets the full stripes assigned to it with the
+			// last stripe of the claim possibly cut short.
+			count := stripes / n
+			if int64(i) < stripes%n {
+				count++
+			}
+
+			size := count * stripeSize
+			if count > 0 && (stripes-1)%n == int64(i) {
+				size -= stripes*stripeSize - puzzle.Claim
+			}
+
+			m.Shards[i] = Shard{Path: path, Size: size}
+		}
+	default:
+		return nil, LayoutError(layout)
+	}
+
+	return m, nil
+}
+
+// Check returns an ImageMismatchError if the manifest was not made for the
+// given puzzle.
+func (m *Manifest) Check(puzzle *Puzzle) error {
+	h := &ImageHeader{
+		Version:  ImageVersion,
+		Claim:    m.Claim,
+		PRNGType: m.PRNGType,
+	}
+	copy(h.SeedHash[:], m.SeedHash)
+
+	if len(m.SeedHash) != sha256.Size {
+		return ImageMismatchError{
+			Field:  "seed hash",
+			Image:  fmt.Sprintf("%x", m.SeedHash),
+			Puzzle: "(sha256)",
+		}
+	}
+
+	return h.Check(puzzle)
+}
+
+// locate returns the shard holding the given offset of the claim, the offset
+// within the shard and the number of bytes from there that are contiguous in
+// the shard.
+func (m *Manifest) locate(offset int64) (shard int, shardOffset, length int64, err error) {
+	switch m.Layout {
+	case LayoutConcat:
+		start := int64(0)
+
+		for i, s := range m.Shards {
+			if offset < start+s.Size {
+				return i, offset - start, start + s.Size - offset, nil
+			}
+
+			start += s.Size
+		}
+
+		return 0, 0, 0, OffsetError(offset)
+	case LayoutStripe:
+		if offset >= m.Claim {
+			return 0, 0, 0, OffsetError(offset)
+		}
+
+		n := int64(len(m.Shards))
+		stripe := offset / m.StripeSize
+		within := offset % m.StripeSize
+
+		return int(stripe % n), (stripe/n)*m.StripeSize + within, m.StripeSize - within, nil
+	default:
+		return 0, 0, 0, LayoutError(m.Layout)
+	}
+}
+
+// ShardImage is the storage backing a shard.
+type ShardImage interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// ShardedDiskSolver is a disk solver that spreads a claim across several
+// backing files or devices as described by a manifest. Prepare generates the
+// PRNG stream once and writes to the shards in parallel, recording the hash of
+// the head of each shard in the manifest. Solve checks the head of each shard
+// and then reads the indices held by each shard in parallel.
+type ShardedDiskSolver struct {
+	BlockSize int   // The size in bytes of the blocks generated by Prepare.
+	Gap       int64 // Indices at most this many bytes apart are read together.
+
+	manifest *Manifest
+	shards   []ShardImage
+}
+
+var _ Solver = (*ShardedDiskSolver)(nil)
+
+func NewShardedDiskSolver(manifest *Manifest, shards []ShardImage) (*ShardedDiskSolver, error) {
+	if len(shards) != len(manifest.Shards) {
+		return nil, ShardCountError(len(shards))
+	}
+
+	return &ShardedDiskSolver{
+		BlockSize: DefaultBlockSize,
+		Gap:       4096,
+
+		manifest: manifest,
+		shards:   shards,
+	}, nil
+}
+
+// piece is a part of a block destined for a shard.
+type piece struct {
+	offset int64
+	data   []byte
+}
+
+func (s *ShardedDiskSolver) Prepare(puzzle *Puzzle) (err error) {
+	if s.BlockSize <= 0 {
+		return BlockSizeError(s.BlockSize)
+	}
+
+	err = s.manifest.Check(puzzle)
+	if err != nil {
+		return err
+	}
+
+	prng, err := puzzle.PRNG.Clone()
+	if err != nil {
+		return err
+	}
+
+	// Each shard has a writer so that slow devices do not hold up the
+	// others. The queues bound the memory used by blocks in flight.
+	queues := make([]chan piece, len(s.shards), len(s.shards))
+	errs := make([]error, len(s.shards), len(s.shards))
+
+	var wg sync.WaitGroup
+
+	for i := range s.shards {
+		queues[i] = make(chan piece, 4)
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			// The pieces for a shard arrive in order so the head can be
+			// hashed as it is written.
+			head := sha256.New()
+
+			for p := range queues[i] {
+				if errs[i] != nil {
+					continue
+				}
+
+				if p.offset < ShardHeadSize {
+					n := ShardHeadSize - p.offset
+					if n > int64(len(p.data)) {
+						n = int64(len(p.data))
+					}
+
+					head.Write(p.data[:n])
+				}
+
+				_, errs[i] = s.shards[i].WriteAt(p.data, p.offset)
+			}
+
+			s.manifest.Shards[i].Head = head.Sum(nil)
+		}(i)
+	}
+
+	newBlock := func() []byte {
+		return make([]byte, s.BlockSize, s.BlockSize)
+	}
+
+	err = generate(prng, 0, puzzle.Claim, newBlock, false, func(i int64, block []byte) error {
+		for len(block) > 0 {
+			shard, offset, length, err := s.manifest.locate(i)
+			if err != nil {
+				return err
+			}
+
+			if length > int64(len(block)) {
+				length = int64(len(block))
+			}
+
+			queues[shard] <- piece{
+				offset: offset,
+				data:   append([]byte(nil), block[:length]...),
+			}
+
+			block = block[length:]
+			i += length
+		}
+
+		return nil
+	})
+
+	for _, q := range queues {
+		close(q)
+	}
+
+	wg.Wait()
+
+	if err != nil {
+		return err
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ShardedDiskSolver) fromIndices(indices []int64) (value []byte, err error) {
+	// Group the indices by shard translating them to shard offsets.
+	shardIndices := make([][]int64, len(s.shards), len(s.shards))
+	shardPositions := make([][]int, len(s.shards), len(s.shards))
+
+	for i, index := range indices {
+		shard, offset, _, err := s.manifest.locate(index)
+		if err != nil {
+			return nil, err
+		}
+
+		shardIndices[shard] = append(shardIndices[shard], offset)
+		shardPositions[shard] = append(shardPositions[shard], i)
+	}
+
+	value = make([]byte, len(indices), len(indices))
+	errs := make([]error, len(s.shards), len(s.shards))
+
+	var wg sync.WaitGroup
+
+	for shard := range s.shards {
+		if len(shardIndices[shard]) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(shard int) {
+			defer wg.Done()
+
+			for _, sp := range spans(shardIndices[shard], s.Gap) {
+				buf := make([]byte, sp.end-sp.start, sp.end-sp.start)
+
+				n, err := s.shards[shard].ReadAt(buf, sp.start)
+				if err != nil && !(err == io.EOF && n == len(buf)) {
+					errs[shard] = err
+
+					return
+				}
+
+				for _, p := range sp.positions {
+					value[shardPositions[shard][p.i]] = buf[p.index-sp.start]
+				}
+			}
+		}(shard)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+// CheckShards returns a ShardMismatchError for the first shard whose head does
+// not match the hash recorded in the manifest.
+func (s *ShardedDiskSolver) CheckShards() (err error) {
+	for i, shard := range s.manifest.Shards {
+		size := shard.Size
+		if size > ShardHeadSize {
+			size = ShardHeadSize
+		}
+
+		buf := make([]byte, size, size)
+
+		n, err := s.shards[i].ReadAt(buf, 0)
+		if err != nil && !(err == io.EOF && n == len(buf)) {
+			return err
+		}
+
+		head := sha256.Sum256(buf)
+
+		if !bytes.Equal(head[:], shard.Head) {
+			return ShardMismatchError(i)
+		}
+	}
+
+	return nil
+}
+
+func (s *ShardedDiskSolver) Solve(puzzle *Puzzle, preseedIndices []int64, mask []byte) (solution []byte, err error) {
+	err = s.manifest.Check(puzzle)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.CheckShards()
+	if err != nil {
+		return nil, err
+	}
+
+	return solve(puzzle, preseedIndices, mask, s.fromIndices)
+}
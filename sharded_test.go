@@ -0,0 +1,77 @@
+package pos_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/calebcase/pos"
+)
+
+func TestShardedDiskSolver(t *testing.T) {
+	puzzle := newPuzzle(t, "aes", 100003)
+	preseedIndices, mask := newChallenge(t, puzzle)
+
+	streamSolver, err := pos.NewStreamSolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := streamSolver.Solve(puzzle, preseedIndices, mask)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, layout := range []string{pos.LayoutConcat, pos.LayoutStripe} {
+		t.Run(layout, func(t *testing.T) {
+			paths := []string{"a", "b", "c"}
+
+			manifest, err := pos.NewManifest(puzzle, layout, 4096, paths)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			shards := make([]pos.ShardImage, len(paths), len(paths))
+
+			for i := range shards {
+				image, remove := tempImage(t)
+				defer remove()
+
+				shards[i] = image
+			}
+
+			solver, err := pos.NewShardedDiskSolver(manifest, shards)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			solver.BlockSize = 1000
+
+			err = solver.Prepare(puzzle)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := solver.Solve(puzzle, preseedIndices, mask)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(actual, expected) {
+				t.Fatalf("sharded solution %x, want %x", actual, expected)
+			}
+
+			// Shards given out of order are detected.
+			shards[0], shards[1] = shards[1], shards[0]
+
+			solver, err = pos.NewShardedDiskSolver(manifest, shards)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = solver.Solve(puzzle, preseedIndices, mask)
+			if err != pos.ShardMismatchError(0) {
+				t.Fatalf("error %v, want %v", err, pos.ShardMismatchError(0))
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package pos_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/calebcase/pos"
+	"github.com/calebcase/pos/lib/aessegprng"
+)
+
+// TestSolvers checks that every solver finds the same solution as the stream
+// solver for random challenges and that the verifier accepts it.
+func TestSolvers(t *testing.T) {
+	const claim = 256*1024 + 3
+
+	// The segmented PRNG uses small segments so the claim spans several.
+	segmented, err := aessegprng.NewSegmentSize(make([]byte, 32, 32), make([]byte, 16, 16), 64*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	puzzles := []struct {
+		name   string
+		puzzle *pos.Puzzle
+	}{
+		{"aes", newPuzzle(t, "aes", claim)},
+		{"aes-ctr", newPuzzle(t, "aes-ctr", claim)},
+		{"aes-seg", &pos.Puzzle{
+			Claim:         claim,
+			PRNG:          segmented,
+			PreseedRounds: 1,
+			IndexSize:     64,
+			SolutionSize:  8,
+		}},
+		{"chacha20", newPuzzle(t, "chacha20", claim)},
+	}
+
+	for _, p := range puzzles {
+		puzzle := p.puzzle
+
+		t.Run(p.name, func(t *testing.T) {
+
+			image, remove := tempImage(t)
+			defer remove()
+
+			diskSolver, err := pos.NewDiskSolver(image)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			concurrentSolver, err := pos.NewConcurrentDiskSolver(image, image, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mmapSolver, err := pos.NewMmapDiskSolver(image)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer mmapSolver.Close()
+
+			memorySolver, err := pos.NewMemorySolver()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			streamSolver, err := pos.NewStreamSolver()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parallelSolver, err := pos.NewParallelStreamSolver(4)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The disk solver prepares the image the other disk solvers
+			// read.
+			solvers := []struct {
+				name   string
+				solver pos.Solver
+			}{
+				{"disk", diskSolver},
+				{"concurrent", concurrentSolver},
+				{"mmap", mmapSolver},
+				{"memory", memorySolver},
+				{"parallel", parallelSolver},
+			}
+
+			for _, s := range solvers {
+				err = s.solver.Prepare(puzzle)
+				if err != nil {
+					t.Fatalf("%s: %v", s.name, err)
+				}
+			}
+
+			verifier, err := pos.NewVerifier()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			size := len(puzzle.PRNG.GetSeed())
+			r := rand.New(rand.NewSource(1))
+
+			for i := 0; i < 10; i++ {
+				seed := make([]byte, size, size)
+				mask := make([]byte, size, size)
+
+				r.Read(seed)
+				r.Read(mask)
+
+				preseedIndices, err := puzzle.PreseedIndices(int64(size), seed)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				expected, err := streamSolver.Solve(puzzle, preseedIndices, mask)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, s := range solvers {
+					actual, err := s.solver.Solve(puzzle, preseedIndices, mask)
+					if err != nil {
+						t.Fatalf("%s: %v", s.name, err)
+					}
+
+					if !bytes.Equal(actual, expected) {
+						t.Fatalf("%s: solution %x, want %x", s.name, actual, expected)
+					}
+				}
+
+				ok, err := verifier.Verify(puzzle, preseedIndices, mask, expected)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !ok {
+					t.Fatalf("verifier rejected %x (mismatch at %d)", expected, verifier.Mismatch)
+				}
+			}
+		})
+	}
+}
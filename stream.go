@@ -28,7 +28,13 @@ func (s *StreamSolver) fromIndices(puzzle *Puzzle, indices []int64) (value []byt
 	last := make([]byte, lastSize, lastSize)
 
 	for i := int64(0); i < puzzle.Claim; i += lastSize {
-		n, err := io.ReadFull(prng, last)
+		// The last block is cut short so the stream stops at the claim.
+		size := int64(lastSize)
+		if puzzle.Claim-i < size {
+			size = puzzle.Claim - i
+		}
+
+		n, err := io.ReadFull(prng, last[:size])
 		if err != nil {
 			return nil, err
 		}
@@ -36,7 +42,7 @@ func (s *StreamSolver) fromIndices(puzzle *Puzzle, indices []int64) (value []byt
 		s.BytesRead += int64(n)
 
 		for _, idx := range indices {
-			if idx >= i && idx < i+lastSize {
+			if idx >= i && idx < i+size {
 				mapper[idx] = last[idx-i]
 
 				if len(mapper) == len(indices) {
@@ -56,36 +62,7 @@ func (s *StreamSolver) fromIndices(puzzle *Puzzle, indices []int64) (value []byt
 }
 
 func (s *StreamSolver) Solve(puzzle *Puzzle, preseedIndices []int64, mask []byte) (solution []byte, err error) {
-	var preseed []byte
-
-	// First Pass: Read all preseed indices and construct the preseed.
-	for i := int64(0); i < puzzle.PreseedRounds; i++ {
-		preseed, err = s.fromIndices(puzzle, preseedIndices)
-		if err != nil {
-			return nil, err
-		}
-
-		preseedIndices, err = puzzle.PreseedIndices(int64(len(preseedIndices)), preseed)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	preseed, err = s.fromIndices(puzzle, preseedIndices)
-	if err != nil {
-		return nil, err
-	}
-
-	// Second Pass: Read all the solution indices and construct the solution.
-	solutionIndices, err := puzzle.SolutionIndices(preseed, mask)
-	if err != nil {
-		return nil, err
-	}
-
-	solution, err = s.fromIndices(puzzle, solutionIndices)
-	if err != nil {
-		return nil, err
-	}
-
-	return solution, nil
+	return solve(puzzle, preseedIndices, mask, func(indices []int64) ([]byte, error) {
+		return s.fromIndices(puzzle, indices)
+	})
 }
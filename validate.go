@@ -0,0 +1,201 @@
+package pos
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNoPRNG is returned when a puzzle has no PRNG.
+var ErrNoPRNG = errors.New("no PRNG")
+
+// DefaultMaxIndexBias is the largest index bias accepted by Puzzle.Validate,
+// 2^-64. See Puzzle.Bias.
+const DefaultMaxIndexBias = 1.0 / (1 << 64)
+
+// MaxIndexSize is the largest index size accepted by Puzzle.Check. An index
+// of this size has a negligible bias for any claim.
+const MaxIndexSize = 256
+
+// MaxSolutionSize is the largest solution size accepted by Puzzle.Check.
+// It bounds the memory and reads needed to solve or verify a challenge.
+const MaxSolutionSize = 1 << 20
+
+// ClaimError is returned when a puzzle's claim is less than 1.
+type ClaimError int64
+
+func (e ClaimError) Error() string {
+	return fmt.Sprintf("Invalid claim %d", int64(e))
+}
+
+// PreseedRoundsError is returned when a puzzle's preseed rounds are negative.
+type PreseedRoundsError int64
+
+func (e PreseedRoundsError) Error() string {
+	return fmt.Sprintf("Invalid preseed rounds %d", int64(e))
+}
+
+// IndexSizeError is returned when a puzzle's index size is less than 1 or
+// more than MaxIndexSize.
+type IndexSizeError int64
+
+func (e IndexSizeError) Error() string {
+	return fmt.Sprintf("Invalid index size %d", int64(e))
+}
+
+// SolutionSizeError is returned when a puzzle's solution size is less than 1
+// or more than MaxSolutionSize.
+type SolutionSizeError int64
+
+func (e SolutionSizeError) Error() string {
+	return fmt.Sprintf("Invalid solution size %d", int64(e))
+}
+
+// IndexCountError is returned when an invalid number of indices is
+// requested.
+type IndexCountError int64
+
+func (e IndexCountError) Error() string {
+	return fmt.Sprintf("Invalid number of indices %d", int64(e))
+}
+
+// MaskSizeError is returned when the size of a mask does not match the size of
+// the puzzle's seed.
+type MaskSizeError struct {
+	Mask int
+	Seed int
+}
+
+func (e MaskSizeError) Error() string {
+	return fmt.Sprintf("Mask size %d does not match seed size %d", e.Mask, e.Seed)
+}
+
+// PreseedSizeError is returned when the number of preseed indices, or the
+// size of a preseed, does not match the size of the puzzle's seed.
+type PreseedSizeError struct {
+	Preseed int
+	Seed    int
+}
+
+func (e PreseedSizeError) Error() string {
+	return fmt.Sprintf("Preseed size %d does not match seed size %d", e.Preseed, e.Seed)
+}
+
+// IndexRangeError is returned when an index is outside of the claim.
+type IndexRangeError int64
+
+func (e IndexRangeError) Error() string {
+	return fmt.Sprintf("Index %d is outside of the claim", int64(e))
+}
+
+// BiasError is returned when a puzzle's index bias exceeds the maximum.
+type BiasError struct {
+	Bias float64
+	Max  float64
+}
+
+func (e BiasError) Error() string {
+	return fmt.Sprintf("Index bias %g exceeds maximum %g (increase the index size)", e.Bias, e.Max)
+}
+
+// Bias returns an upper bound on the statistical distance between the
+// distribution of the selected indices and the uniform distribution over the
+// claim.
+//
+// Indices are selected by reducing a random IndexSize byte integer modulo the
+// claim. When the claim does not evenly divide 2^(8*IndexSize) the lowest
+// indices are slightly more likely than the others. The distance is at most
+// Claim / 2^(8*IndexSize) and is negligible for the default index size.
+func (p *Puzzle) Bias() float64 {
+	if p.IndexSize <= 0 {
+		return 1
+	}
+
+	if p.IndexSize > math.MaxInt32/8 {
+		return 0
+	}
+
+	bias := math.Ldexp(float64(p.Claim), -8*int(p.IndexSize))
+	if bias > 1 {
+		return 1
+	}
+
+	return bias
+}
+
+// Validate checks the puzzle with ValidateBias(DefaultMaxIndexBias).
+func (p *Puzzle) Validate() error {
+	return p.ValidateBias(DefaultMaxIndexBias)
+}
+
+// ValidateBias checks the puzzle with Check and that its index bias is at most
+// max. The bias only favors the prover, so it is checked by whoever creates a
+// puzzle or accepts a proof for one it did not create.
+func (p *Puzzle) ValidateBias(max float64) error {
+	err := p.Check()
+	if err != nil {
+		return err
+	}
+
+	bias := p.Bias()
+	if bias > max {
+		return BiasError{bias, max}
+	}
+
+	return nil
+}
+
+// Check checks that the puzzle's parameters are usable. Each problem is
+// reported with its own error type. It does not check the index bias.
+func (p *Puzzle) Check() error {
+	if p.Claim <= 0 {
+		return ClaimError(p.Claim)
+	}
+
+	if p.PRNG == nil {
+		return ErrNoPRNG
+	}
+
+	if p.PreseedRounds < 0 {
+		return PreseedRoundsError(p.PreseedRounds)
+	}
+
+	if p.IndexSize <= 0 || p.IndexSize > MaxIndexSize {
+		return IndexSizeError(p.IndexSize)
+	}
+
+	if p.SolutionSize <= 0 || p.SolutionSize > MaxSolutionSize {
+		return SolutionSizeError(p.SolutionSize)
+	}
+
+	return nil
+}
+
+// ValidateChallenge checks the puzzle with Check and that the preseed
+// indices and mask can be used to solve it. There must be one preseed index
+// for each byte of the seed, each inside the claim, and the mask must be the
+// size of the seed.
+func (p *Puzzle) ValidateChallenge(preseedIndices []int64, mask []byte) error {
+	err := p.Check()
+	if err != nil {
+		return err
+	}
+
+	size := len(p.PRNG.GetSeed())
+
+	if len(mask) != size {
+		return MaskSizeError{len(mask), size}
+	}
+
+	if len(preseedIndices) != size {
+		return PreseedSizeError{len(preseedIndices), size}
+	}
+
+	for _, index := range preseedIndices {
+		if index < 0 || index >= p.Claim {
+			return IndexRangeError(index)
+		}
+	}
+
+	return nil
+}
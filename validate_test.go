@@ -0,0 +1,231 @@
+package pos_test
+
+import (
+	"testing"
+
+	"github.com/calebcase/pos"
+)
+
+// chiSquared returns Pearson's chi-squared statistic for the counts of
+// indices drawn from the claim against the uniform distribution.
+func chiSquared(t *testing.T, puzzle *pos.Puzzle) float64 {
+	t.Helper()
+
+	size := len(puzzle.PRNG.GetSeed())
+
+	preseed := make([]byte, size, size)
+	mask := make([]byte, size, size)
+	for i := range preseed {
+		preseed[i] = byte(i)
+	}
+
+	indices, err := puzzle.SolutionIndices(preseed, mask)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make([]int64, puzzle.Claim, puzzle.Claim)
+	for _, index := range indices {
+		counts[index]++
+	}
+
+	expected := float64(len(indices)) / float64(puzzle.Claim)
+
+	chi2 := 0.0
+	for _, count := range counts {
+		d := float64(count) - expected
+		chi2 += d * d / expected
+	}
+
+	return chi2
+}
+
+func TestIndexUniformity(t *testing.T) {
+	// The critical value of the chi-squared distribution with 199 degrees
+	// of freedom at a significance of 0.001.
+	const critical = 267.4
+
+	for _, prngType := range []string{"aes", "chacha20"} {
+		t.Run(prngType, func(t *testing.T) {
+			puzzle := newPuzzle(t, prngType, 200)
+			puzzle.SolutionSize = 200000
+
+			err := puzzle.Validate()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			chi2 := chiSquared(t, puzzle)
+			if chi2 > critical {
+				t.Fatalf("chi-squared %.2f exceeds %.2f", chi2, critical)
+			}
+
+			// With a one byte index the lowest 56 indices are twice as
+			// likely as the others.
+			puzzle.IndexSize = 1
+
+			chi2 = chiSquared(t, puzzle)
+			if chi2 <= critical {
+				t.Fatalf("chi-squared %.2f of a biased puzzle does not exceed %.2f", chi2, critical)
+			}
+
+			_, ok := puzzle.Validate().(pos.BiasError)
+			if !ok {
+				t.Fatal("biased puzzle is valid")
+			}
+		})
+	}
+}
+
+func TestValidateBias(t *testing.T) {
+	puzzle := newPuzzle(t, "aes", 1024)
+	puzzle.IndexSize = 2
+
+	// The bias is 1024 / 2^16.
+	err := puzzle.ValidateBias(1.0 / 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = puzzle.ValidateBias(1.0 / 128)
+	if err != (pos.BiasError{Bias: 1.0 / 64, Max: 1.0 / 128}) {
+		t.Fatalf("error %v, want BiasError", err)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(p *pos.Puzzle)
+		err    error
+	}{
+		{"claim", func(p *pos.Puzzle) { p.Claim = 0 }, pos.ClaimError(0)},
+		{"prng", func(p *pos.Puzzle) { p.PRNG = nil }, pos.ErrNoPRNG},
+		{"preseed rounds", func(p *pos.Puzzle) { p.PreseedRounds = -1 }, pos.PreseedRoundsError(-1)},
+		{"index size zero", func(p *pos.Puzzle) { p.IndexSize = 0 }, pos.IndexSizeError(0)},
+		{"index size large", func(p *pos.Puzzle) { p.IndexSize = 1 << 40 }, pos.IndexSizeError(1 << 40)},
+		{"solution size zero", func(p *pos.Puzzle) { p.SolutionSize = 0 }, pos.SolutionSizeError(0)},
+		{"solution size large", func(p *pos.Puzzle) { p.SolutionSize = 1 << 62 }, pos.SolutionSizeError(1 << 62)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puzzle := newPuzzle(t, "aes", 4096)
+			tt.modify(puzzle)
+
+			err := puzzle.Validate()
+			if err != tt.err {
+				t.Fatalf("error %v, want %v", err, tt.err)
+			}
+
+			preseedIndices, mask := newChallenge(t, newPuzzle(t, "aes", 4096))
+
+			err = puzzle.ValidateChallenge(preseedIndices, mask)
+			if err != tt.err {
+				t.Fatalf("challenge error %v, want %v", err, tt.err)
+			}
+
+			solver, err := pos.NewStreamSolver()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = solver.Solve(puzzle, preseedIndices, mask)
+			if err != tt.err {
+				t.Fatalf("solve error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidateChallengeErrors(t *testing.T) {
+	puzzle := newPuzzle(t, "aes", 4096)
+	size := len(puzzle.PRNG.GetSeed())
+
+	tests := []struct {
+		name   string
+		modify func(preseedIndices []int64, mask []byte) ([]int64, []byte)
+		err    error
+	}{
+		{"mask size", func(preseedIndices []int64, mask []byte) ([]int64, []byte) {
+			return preseedIndices, mask[1:]
+		}, pos.MaskSizeError{Mask: size - 1, Seed: size}},
+		{"preseed size", func(preseedIndices []int64, mask []byte) ([]int64, []byte) {
+			return preseedIndices[1:], mask
+		}, pos.PreseedSizeError{Preseed: size - 1, Seed: size}},
+		{"negative index", func(preseedIndices []int64, mask []byte) ([]int64, []byte) {
+			preseedIndices[0] = -1
+
+			return preseedIndices, mask
+		}, pos.IndexRangeError(-1)},
+		{"index past claim", func(preseedIndices []int64, mask []byte) ([]int64, []byte) {
+			preseedIndices[0] = puzzle.Claim
+
+			return preseedIndices, mask
+		}, pos.IndexRangeError(puzzle.Claim)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preseedIndices, mask := tt.modify(newChallenge(t, puzzle))
+
+			err := puzzle.ValidateChallenge(preseedIndices, mask)
+			if err != tt.err {
+				t.Fatalf("error %v, want %v", err, tt.err)
+			}
+
+			solver, err := pos.NewStreamSolver()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = solver.Solve(puzzle, preseedIndices, mask)
+			if err != tt.err {
+				t.Fatalf("solve error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// TestBiasedPuzzle checks that a puzzle created with a relaxed bias limit can
+// be solved and verified.
+func TestBiasedPuzzle(t *testing.T) {
+	puzzle := newPuzzle(t, "aes", 4096)
+	puzzle.IndexSize = 2
+
+	_, ok := puzzle.Validate().(pos.BiasError)
+	if !ok {
+		t.Fatal("biased puzzle is valid")
+	}
+
+	err := puzzle.ValidateBias(1.0 / 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	preseedIndices, mask := newChallenge(t, puzzle)
+
+	solver, err := pos.NewStreamSolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solution, err := solver.Solve(puzzle, preseedIndices, mask)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := pos.NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err = verifier.Verify(puzzle, preseedIndices, mask, solution)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ok {
+		t.Fatal("verifier rejected the solution")
+	}
+}
@@ -0,0 +1,106 @@
+package pos
+
+import "errors"
+
+// ErrNoPuzzle is returned when verifying or issuing a challenge without a
+// puzzle.
+var ErrNoPuzzle = errors.New("no puzzle")
+
+// Verifier checks a solution to a puzzle without storing the claimed space.
+// It follows the two pass method described in the README: the PRNG stream is
+// regenerated to recover the preseed and then again to recover the solution.
+// If the puzzle's PRNG is a SeekablePRNG the bytes at each index are computed
+// directly instead.
+type Verifier struct {
+	BytesRead int64 // The number of PRNG bytes consumed by the last Verify.
+	Mismatch  int   // The position of the first mismatched solution byte or -1.
+}
+
+func NewVerifier() (*Verifier, error) {
+	return &Verifier{
+		Mismatch: -1,
+	}, nil
+}
+
+// Verify computes the expected solution for the given preseed indices and
+// mask and compares it to solution. If they differ, ok is false and Mismatch
+// is set to the position of the first differing byte.
+func (v *Verifier) Verify(puzzle *Puzzle, preseedIndices []int64, mask, solution []byte) (ok bool, err error) {
+	v.BytesRead = 0
+	v.Mismatch = -1
+
+	if puzzle == nil {
+		return false, ErrNoPuzzle
+	}
+
+	err = puzzle.ValidateChallenge(preseedIndices, mask)
+	if err != nil {
+		return false, err
+	}
+
+	var expected []byte
+
+	prng, err := puzzle.PRNG.Clone()
+	if err != nil {
+		return false, err
+	}
+
+	if seekable, ok := prng.(SeekablePRNG); ok {
+		// The bytes at each index can be computed directly.
+		expected, err = solve(puzzle, preseedIndices, mask, func(indices []int64) ([]byte, error) {
+			value, read, err := seekIndices(seekable, indices)
+			v.BytesRead += read
+
+			return value, err
+		})
+		if err != nil {
+			return false, err
+		}
+	} else {
+		streamSolver, err := NewStreamSolver()
+		if err != nil {
+			return false, err
+		}
+
+		expected, err = streamSolver.Solve(puzzle, preseedIndices, mask)
+		v.BytesRead = streamSolver.BytesRead
+		if err != nil {
+			return false, err
+		}
+	}
+
+	for i := range expected {
+		if i >= len(solution) || expected[i] != solution[i] {
+			v.Mismatch = i
+
+			return false, nil
+		}
+	}
+
+	if len(solution) != len(expected) {
+		v.Mismatch = len(expected)
+
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// VerifyProof checks a non-interactive proof by deriving its challenge with
+// DeriveChallenge and verifying the solution with Verify. It does not check
+// the puzzle's index bias; see Puzzle.ValidateBias.
+func (v *Verifier) VerifyProof(proof *Proof) (ok bool, err error) {
+	v.BytesRead = 0
+	v.Mismatch = -1
+
+	if proof.Puzzle == nil {
+		return false, ErrNoPuzzle
+	}
+
+	preseedIndices, mask, err := DeriveChallenge(proof.Puzzle, proof.Beacon)
+	if err != nil {
+		return false, err
+	}
+
+	return v.Verify(proof.Puzzle, preseedIndices, mask, proof.Solution)
+}
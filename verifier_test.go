@@ -0,0 +1,54 @@
+package pos_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/calebcase/pos"
+)
+
+func TestVerifyInvalid(t *testing.T) {
+	verifier, err := pos.NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = verifier.Verify(nil, nil, nil, nil)
+	if err != pos.ErrNoPuzzle {
+		t.Fatalf("error %v, want %v", err, pos.ErrNoPuzzle)
+	}
+
+	puzzle := newPuzzle(t, "aes", 4096)
+	preseedIndices, mask := newChallenge(t, puzzle)
+
+	_, err = verifier.Verify(puzzle, preseedIndices, mask[1:], nil)
+	if _, ok := err.(pos.MaskSizeError); !ok {
+		t.Fatalf("error %v, want MaskSizeError", err)
+	}
+
+	puzzle.PRNG = nil
+
+	_, err = verifier.Verify(puzzle, preseedIndices, mask, nil)
+	if err != pos.ErrNoPRNG {
+		t.Fatalf("error %v, want %v", err, pos.ErrNoPRNG)
+	}
+
+	session, err := pos.NewSession(puzzle, time.Second, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = session.Issue()
+	if err != pos.ErrNoPRNG {
+		t.Fatalf("error %v, want %v", err, pos.ErrNoPRNG)
+	}
+}
+
+func TestSolutionIndicesMaskSize(t *testing.T) {
+	puzzle := newPuzzle(t, "aes", 4096)
+
+	_, err := puzzle.SolutionIndices(make([]byte, 48, 48), make([]byte, 47, 47))
+	if err != (pos.MaskSizeError{Mask: 47, Seed: 48}) {
+		t.Fatalf("error %v, want MaskSizeError", err)
+	}
+}